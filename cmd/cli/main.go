@@ -3,16 +3,21 @@ package main
 import (
 	"context"
 	"database/sql"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
-	"git.rileymathews.com/riley/pr-tracker/internal/core"
-	"git.rileymathews.com/riley/pr-tracker/internal/db/gen"
 	"git.rileymathews.com/riley/pr-tracker/internal/db/repository"
 	"git.rileymathews.com/riley/pr-tracker/internal/github"
 	"git.rileymathews.com/riley/pr-tracker/internal/models"
 	"git.rileymathews.com/riley/pr-tracker/internal/service"
+	prsync "git.rileymathews.com/riley/pr-tracker/internal/sync"
 	_ "modernc.org/sqlite"
 )
 
@@ -22,7 +27,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	dbConn, err := sql.Open("sqlite", "./db.sqlite3")
+	dbConn, err := sql.Open("sqlite", "./db.sqlite3?_pragma=busy_timeout(5000)")
 	if err != nil {
 		log.Fatalf("open sqlite db failed: %v", err)
 	}
@@ -32,22 +37,25 @@ func main() {
 		}
 	}()
 
-	ctx := context.Background()
+	// Ctrl-C cancels whatever is in flight; a sync stops cleanly and is
+	// recorded as cancelled.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
 	if err := repository.ApplyMigrations(ctx, dbConn, "internal/db/migrations"); err != nil {
 		log.Fatalf("apply sqlite migrations failed: %v", err)
 	}
 
-	queries := gen.New(dbConn)
-	repo := repository.New(queries, ctx)
+	repo := repository.New(dbConn)
 
 	if os.Args[1] == "auth" {
 		log.Println("Authenticating user...")
-		dispatchAuthCommand(repo, os.Args[2:])
+		dispatchAuthCommand(ctx, repo, os.Args[2:])
 		log.Println("User authenticated successfully")
 		os.Exit(0)
 	}
 
-	user, err := repo.GetUser()
+	user, err := repo.GetUser(ctx)
 	if err != nil {
 		log.Fatalf("fetch user failed: %v", err)
 	}
@@ -57,16 +65,25 @@ func main() {
 
 	switch os.Args[1] {
 	case "authors":
-		dispatchAuthorsCommand(repo, os.Args[2:])
+		dispatchAuthorsCommand(ctx, repo, os.Args[2:])
 
 	case "repositories":
-		dispatchRepositoriesCommand(repo, os.Args[2:])
+		dispatchRepositoriesCommand(ctx, repo, os.Args[2:])
 
 	case "sync":
-		dispatchSyncCommand(repo, user.AccessToken)
+		dispatchSyncCommand(ctx, repo, user.AccessToken, os.Args[2:])
+
+	case "hosts":
+		dispatchHostsCommand(ctx, repo, os.Args[2:])
+
+	case "commenters":
+		dispatchCommentersCommand(ctx, repo, user, os.Args[2:])
 
 	case "prs":
-		dispatchPrsCommand(repo)
+		dispatchPrsCommand(ctx, repo, os.Args[2:])
+
+	case "status":
+		displayStatus(ctx, repo)
 	default:
 		fmt.Printf("Unknown command: %s\n", os.Args[1])
 		printUsage()
@@ -74,9 +91,9 @@ func main() {
 	}
 }
 
-func dispatchAuthCommand(repo *repository.DatabaseRepository, args []string) {
+func dispatchAuthCommand(ctx context.Context, repo *repository.DatabaseRepository, args []string) {
 	// ensure we don't already have a user configured
-	maybeUser, err := repo.GetUser()
+	maybeUser, err := repo.GetUser(ctx)
 	if err != nil {
 		log.Fatalf("fetch user failed: %v", err)
 	}
@@ -91,7 +108,11 @@ func dispatchAuthCommand(repo *repository.DatabaseRepository, args []string) {
 		os.Exit(1)
 	}
 	auth_token := args[0]
-	user, err := github.FetchAuthenticatedUser(auth_token)
+	client, err := newHostClient(ctx, repo, models.DefaultHost, auth_token)
+	if err != nil {
+		log.Fatalf("create github client failed: %v", err)
+	}
+	user, err := client.FetchAuthenticatedUser(ctx)
 	if err != nil {
 		log.Fatalf("fetch authenticated user failed: %v", err)
 	}
@@ -102,87 +123,458 @@ func dispatchAuthCommand(repo *repository.DatabaseRepository, args []string) {
 		AccessToken: auth_token,
 	}
 
-	if err := repo.SaveUser(user_model); err != nil {
+	if err := repo.SaveUser(ctx, user_model); err != nil {
 		log.Fatalf("save user failed: %v", err)
 	}
 }
 
 
-func dispatchPrsCommand(repo *repository.DatabaseRepository) {
-	prs, err := repo.GetAllPrs()
+func dispatchPrsCommand(ctx context.Context, repo *repository.DatabaseRepository, args []string) {
+	if len(args) > 0 && args[0] == "show" {
+		showPullRequest(ctx, repo, args[1:])
+		return
+	}
+
+	flags := flag.NewFlagSet("prs", flag.ExitOnError)
+	stateName := flags.String("state", string(models.PullRequestStateOpen), "which pull requests to list: open, merged or closed")
+	limit := flags.Int("limit", 20, "how many merged or closed pull requests to list, most recently closed first")
+	if err := flags.Parse(args); err != nil {
+		log.Fatalf("parse prs flags failed: %v", err)
+	}
+	if flags.NArg() > 0 {
+		printUsage()
+		os.Exit(1)
+	}
+	state, err := models.ParsePullRequestState(*stateName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if state != models.PullRequestStateOpen {
+		displayArchivedPullRequests(ctx, repo, state, *limit)
+		return
+	}
+
+	prs, err := repo.GetAllPrs(ctx)
 	if err != nil {
 		log.Fatalf("fetch prs failed: %v", err)
 	}
 	fmt.Printf("PRs:\n")
 	for _, pr := range prs {
 		fmt.Printf("- #%d: %s (Repository: %s, Author: %s)\n", pr.Number, pr.Title, pr.Repository, pr.Author)
+		if pr.ReviewDecision != models.ReviewDecisionNone {
+			fmt.Printf("    Review: %s\n", pr.ReviewDecision)
+		}
+		for _, check := range pr.ChecksWithOutcome(models.CheckOutcomeFailing) {
+			fmt.Printf("    Failing: %s %s\n", check.Label(), check.DetailsURL)
+		}
+		if note := pr.MergeNote(); note != "" {
+			fmt.Printf("    %s\n", note)
+		}
+		if note := pr.StalenessNote(); note != "" {
+			fmt.Printf("    %s\n", note)
+		}
 	}
 }
 
-func dispatchSyncCommand(repo *repository.DatabaseRepository, token string) {
-	fmt.Println("Syncing data...")
-	
-	repositories, err := repo.GetTrackedRepositories()
+func displayArchivedPullRequests(ctx context.Context, repo *repository.DatabaseRepository, state models.PullRequestState, limit int) {
+	prs, err := repo.GetPrsByState(ctx, state, limit)
 	if err != nil {
-		log.Fatalf("fetch tracked repositories failed: %v", err)
+		log.Fatalf("fetch %s prs failed: %v", string(state), err)
 	}
-	if len(repositories) == 0 {
-		fmt.Println("No repositories to sync")
-		return
+
+	fmt.Printf("%s PRs:\n", state)
+	for _, pr := range prs {
+		fmt.Printf("- #%d: %s (Repository: %s, Author: %s)\n", pr.Number, pr.Title, pr.Repository, pr.Author)
+		fmt.Printf("    %s\n", closedSummary(pr))
+	}
+}
+
+// closedSummary says when and, for merged pull requests, by whom a pull
+// request was closed.
+func closedSummary(pr *models.PullRequest) string {
+	if pr.State != models.PullRequestStateMerged {
+		return fmt.Sprintf("Closed %s", pr.ClosedAt.Local().Format("2006-01-02 15:04"))
+	}
+
+	summary := fmt.Sprintf("Merged %s", pr.MergedAt.Local().Format("2006-01-02 15:04"))
+	if pr.MergedBy != "" {
+		summary += " by " + pr.MergedBy
 	}
-	trackedAuthors, err := repo.GetTrackedAuthors()
+	return summary
+}
+
+func showPullRequest(ctx context.Context, repo *repository.DatabaseRepository, args []string) {
+	if len(args) < 1 {
+		printUsage()
+		os.Exit(1)
+	}
+	repoName, number, err := models.ParsePullRequestReference(args[0])
 	if err != nil {
-		log.Fatalf("fetch tracked authors failed: %v", err)
+		log.Fatal(err)
 	}
-	if len(trackedAuthors) == 0 {
-		fmt.Println("No authors to sync")
+
+	pr, err := repo.GetPr(ctx, repoName, number)
+	if err != nil {
+		log.Fatalf("fetch pr failed: %v", err)
+	}
+	if pr == nil {
+		log.Fatalf("%s is not tracked", args[0])
+	}
+	events, err := repo.GetPrEvents(ctx, repoName, number)
+	if err != nil {
+		log.Fatalf("fetch pr events failed: %v", err)
+	}
+
+	state := "Open"
+	switch {
+	case pr.State == models.PullRequestStateMerged || pr.State == models.PullRequestStateClosed:
+		state = closedSummary(pr)
+	case pr.Draft:
+		state = "Draft"
+	}
+
+	fmt.Printf("%s: %s\n", pr.Reference(), pr.Title)
+	fmt.Printf("  Author:    %s\n", pr.Author)
+	fmt.Printf("  State:     %s\n", state)
+	fmt.Printf("  CI:        %s\n", pr.CiStatus)
+	for _, outcome := range []models.CheckOutcome{models.CheckOutcomeFailing, models.CheckOutcomePending} {
+		for _, check := range pr.ChecksWithOutcome(outcome) {
+			label := check.Label()
+			if check.Required {
+				label += " [required]"
+			}
+			fmt.Printf("             %-7s %s  %s\n", outcome, label, check.DetailsURL)
+		}
+	}
+	fmt.Printf("  Review:    %s\n", pr.ReviewDecision)
+	for _, review := range pr.Reviews {
+		fmt.Printf("             %s: %s\n", review.Reviewer, review.State)
+	}
+	if len(pr.RequestedReviewers) > 0 {
+		fmt.Printf("  Requested: %s\n", strings.Join(pr.RequestedReviewers, ", "))
+	}
+	switch {
+	case pr.BaseRef != "" && pr.BehindBy > 0:
+		fmt.Printf("  Base:      %s, %d commits behind\n", pr.BaseRef, pr.BehindBy)
+	case pr.BaseRef != "":
+		fmt.Printf("  Base:      %s\n", pr.BaseRef)
+	}
+	if note := pr.MergeNote(); note != "" {
+		fmt.Printf("  Merge:     %s\n", note)
+	}
+	if pr.HeadSHA != "" {
+		fmt.Printf("  Head:      %.7s, committed %s\n", pr.HeadSHA, pr.LastCommitAt.Local().Format("2006-01-02 15:04"))
+	}
+	fmt.Printf("  Updated:   %s\n", pr.UpdatedAt.Local().Format("2006-01-02 15:04"))
+	fmt.Printf("  URL:       %s\n", pr.Url())
+	if note := pr.StalenessNote(); note != "" {
+		fmt.Printf("  %s\n", note)
+	}
+
+	fmt.Println("Timeline:")
+	if len(events) == 0 {
+		fmt.Println("  No changes recorded yet")
 		return
 	}
+	for _, event := range events {
+		fmt.Printf("  %s  %s\n", event.At.Local().Format("2006-01-02 15:04"), event)
+	}
+}
 
-	for _, repository := range repositories {
-		fmt.Printf("Syncing repository: %s\n", repository)
-		newData, err := service.FetchTrackedPullRequests(repository, trackedAuthors, token)
+func dispatchSyncCommand(ctx context.Context, repo *repository.DatabaseRepository, token string, args []string) {
+	if len(args) > 0 {
+		switch args[0] {
+		case "history":
+			displaySyncHistory(ctx, repo, args[1:])
+			return
+		case "last":
+			displayLastSync(ctx, repo)
+			return
+		}
+	}
+
+	flags := flag.NewFlagSet("sync", flag.ExitOnError)
+	parallelism := flags.Int("parallelism", service.DefaultParallelism, "maximum number of GitHub fetches in flight at once")
+	full := flags.Bool("full", false, "fetch every pull request in full, even ones that look unchanged")
+	recordPath := flags.String("record", "", "save every GitHub request and response, with tokens removed, to this file")
+	replayPath := flags.String("replay", "", "answer GitHub requests from a file saved with -record instead of the network, syncing a throwaway copy of the database")
+	if err := flags.Parse(args); err != nil {
+		log.Fatalf("parse sync flags failed: %v", err)
+	}
+	if *recordPath != "" && *replayPath != "" {
+		log.Fatal("-record and -replay can't be used together")
+	}
+
+	opts := prsync.Options{Parallelism: *parallelism, Full: *full, GitHub: github.Config{Cache: repo}}
+	syncRepo := repo
+	cleanup := func() {}
+	var recorder *github.Recorder
+	switch {
+	case *recordPath != "":
+		recorder = github.NewRecorder()
+		opts.GitHub.WrapTransport = recorder.Transport
+	case *replayPath != "":
+		cassette, err := github.LoadCassette(*replayPath)
 		if err != nil {
-			log.Printf("fetch open prs for repository %s failed: %v", repository, err)
-			continue
+			log.Fatalf("load replay file failed: %v", err)
+		}
+		replayer, err := github.NewReplayer(cassette)
+		if err != nil {
+			log.Fatalf("load replay file failed: %v", err)
 		}
-		log.Printf("fetched %d open prs for repository %s", len(newData), repository)
-		existingPrs, err := repo.GetPrsByRepository(repository)
+		opts.GitHub.WrapTransport = replayer.Transport
+		// Replayed responses are always complete, and shouldn't end up in
+		// the cache used by real syncs.
+		opts.GitHub.Cache = nil
+
+		// A replay is a dry run: whatever it syncs goes into a copy of the
+		// database that is thrown away afterwards.
+		syncRepo, cleanup, err = replayRepository(ctx, repo)
 		if err != nil {
-			log.Printf("fetch existing prs for repository %s failed: %v", repository, err)
+			log.Fatalf("copy database for replay failed: %v", err)
+		}
+		fmt.Println("Replaying against a copy of the database, nothing will be saved")
+	}
+
+	fmt.Println("Syncing data...")
+
+	report, err := prsync.Run(ctx, syncRepo, token, opts)
+	cleanup()
+	if recorder != nil {
+		cassette := recorder.Cassette()
+		if saveErr := cassette.Save(*recordPath); saveErr != nil {
+			log.Printf("save recording failed: %v", saveErr)
+		} else {
+			fmt.Printf("Recorded %d GitHub requests to %s\n", len(cassette.Interactions), *recordPath)
+		}
+	}
+	if err != nil {
+		log.Fatalf("sync failed: %v", err)
+	}
+	printSyncReport(report)
+}
+
+// replayRepository copies the database to a temporary directory for
+// 'sync -replay' to write to. The returned cleanup closes the copy and
+// removes it.
+func replayRepository(ctx context.Context, repo *repository.DatabaseRepository) (*repository.DatabaseRepository, func(), error) {
+	dir, err := os.MkdirTemp("", "pr-tracker-replay-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("create temporary directory: %w", err)
+	}
+
+	path := filepath.Join(dir, "db.sqlite3")
+	if err := repo.CopyTo(ctx, path); err != nil {
+		os.RemoveAll(dir)
+		return nil, nil, err
+	}
+	dbConn, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, nil, fmt.Errorf("open database copy: %w", err)
+	}
+
+	cleanup := func() {
+		if err := dbConn.Close(); err != nil {
+			log.Printf("close database copy failed: %v", err)
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			log.Printf("remove database copy failed: %v", err)
+		}
+	}
+	return repository.New(dbConn), cleanup, nil
+}
+
+func printSyncReport(report *prsync.SyncReport) {
+	if len(report.Repositories) == 0 {
+		fmt.Println("Nothing to sync, add repositories and authors first")
+		return
+	}
+
+	for _, repoReport := range report.Repositories {
+		if repoReport.Err != nil {
+			fmt.Printf("- %s: failed: %v\n", repoReport.Repository, repoReport.Err)
 			continue
 		}
-		
-		newPrs, updatedPrs, deletedPrs := core.ProcessPullRequestSyncResults(existingPrs, newData)
-		for _, pr := range newPrs {
-			if err := repo.SavePr(pr); err != nil {
-				log.Printf("save pr #%d for repository %s failed: %v", pr.Number, repository, err)
-				continue
+
+		fmt.Printf("- %s: %d new, %d updated, %d archived, %d deleted, %d unchanged (fetched in %s, %d API calls)\n",
+			repoReport.Repository,
+			len(repoReport.NewPrs),
+			len(repoReport.UpdatedPrs),
+			len(repoReport.ArchivedPrs),
+			len(repoReport.DeletedPrs),
+			repoReport.Unchanged,
+			repoReport.Duration.Round(time.Millisecond),
+			repoReport.APICalls,
+		)
+		for _, pr := range repoReport.NewPrs {
+			fmt.Printf("    new     #%d: %s\n", pr.Number, pr.Title)
+		}
+		for _, pr := range repoReport.UpdatedPrs {
+			fmt.Printf("    updated #%d: %s\n", pr.Number, pr.Title)
+			for _, event := range repoReport.PullRequestEvents(pr.Number) {
+				fmt.Printf("      %s\n", event)
 			}
-			log.Printf("saved new pr #%d for repository %s", pr.Number, repository)
 		}
+		for _, pr := range repoReport.ArchivedPrs {
+			fmt.Printf("    %-7s #%d: %s\n", strings.ToLower(pr.State.String()), pr.Number, pr.Title)
+		}
+		for _, pr := range repoReport.DeletedPrs {
+			fmt.Printf("    deleted #%d: %s\n", pr.Number, pr.Title)
+		}
+		for _, failure := range repoReport.FailedPrs {
+			fmt.Printf("    stale   #%d: %v\n", failure.Number, failure.Err)
+		}
+	}
 
-		for _, pr := range updatedPrs {
-			if err := repo.SavePr(pr); err != nil {
-				log.Printf("update pr #%d for repository %s failed: %v", pr.Number, repository, err)
-				continue
-			}
-			log.Printf("updated pr #%d for repository %s", pr.Number, repository)
+	newCount, updatedCount, deletedCount := report.Totals()
+	fmt.Printf("Synced %d repositories in %s using %d API calls (%d cached, %d retried): %d new, %d updated, %d archived, %d deleted, %d unchanged, %d stale, %d failed\n",
+		len(report.Repositories),
+		report.Duration().Round(time.Millisecond),
+		report.APICalls,
+		report.CachedResponses,
+		report.Retries,
+		newCount,
+		updatedCount,
+		report.ArchivedCount(),
+		deletedCount,
+		report.UnchangedCount(),
+		report.StaleCount(),
+		len(report.Failed()),
+	)
+	if report.RateLimit.Known() {
+		fmt.Printf("API quota left: %d of %d, resets at %s\n",
+			report.RateLimit.Remaining,
+			report.RateLimit.Limit,
+			report.RateLimit.Reset.Local().Format("15:04"),
+		)
+	}
+}
+
+func displayStatus(ctx context.Context, repo *repository.DatabaseRepository) {
+	runs, err := repo.GetRecentSyncRuns(ctx, 1)
+	if err != nil {
+		log.Fatalf("fetch last sync failed: %v", err)
+	}
+	if len(runs) == 0 {
+		fmt.Println("No syncs have run yet")
+		return
+	}
+	run := runs[0]
+
+	fmt.Printf("Last sync:  #%d %s at %s (%s ago), took %s\n",
+		run.ID,
+		run.Outcome,
+		run.StartedAt.Local().Format("2006-01-02 15:04:05"),
+		time.Since(run.FinishedAt).Round(time.Second),
+		run.Duration().Round(time.Second),
+	)
+	fmt.Printf("Quota used: %d requests (%d more answered from cache for free)\n", run.QuotaUsed(), run.CachedResponses)
+
+	if run.RateLimit == 0 {
+		fmt.Println("Quota left: unknown, the last sync made no requests")
+		return
+	}
+
+	reset := "already reset"
+	if untilReset := time.Until(run.RateLimitReset); untilReset > 0 {
+		reset = fmt.Sprintf("resets at %s, in %s", run.RateLimitReset.Local().Format("15:04"), untilReset.Round(time.Minute))
+	}
+	fmt.Printf("Quota left: %d of %d (%s)\n", run.RateLimitRemaining, run.RateLimit, reset)
+}
+
+func displaySyncHistory(ctx context.Context, repo *repository.DatabaseRepository, args []string) {
+	limit := 10
+	if len(args) > 0 {
+		parsed, err := strconv.Atoi(args[0])
+		if err != nil || parsed <= 0 {
+			fmt.Printf("Invalid number of runs: %s\n", args[0])
+			printUsage()
+			os.Exit(1)
 		}
+		limit = parsed
+	}
 
-		for _, pr := range deletedPrs {
-			if err := repo.DeletePr(pr.Repository, pr.Number); err != nil {
-				log.Printf("delete pr #%d for repository %s failed: %v", pr.Number, repository, err)
-				continue
-			}
-			log.Printf("deleted pr #%d for repository %s", pr.Number, repository)
+	runs, err := repo.GetRecentSyncRuns(ctx, limit)
+	if err != nil {
+		log.Fatalf("fetch sync history failed: %v", err)
+	}
+	if len(runs) == 0 {
+		fmt.Println("No syncs have run yet")
+		return
+	}
+
+	fmt.Println("Sync history:")
+	for _, run := range runs {
+		fmt.Printf("- #%d %s %-9s %6s  %d new, %d updated, %d removed, %d stale, %d API calls (%d cached, %d retried)\n",
+			run.ID,
+			run.StartedAt.Local().Format("2006-01-02 15:04:05"),
+			run.Outcome,
+			run.Duration().Round(time.Second),
+			run.NewCount,
+			run.UpdatedCount,
+			run.RemovedCount,
+			run.StaleCount,
+			run.APICalls,
+			run.CachedResponses,
+			run.Retries,
+		)
+		if run.Error != "" {
+			fmt.Printf("    error: %s\n", run.Error)
 		}
 	}
 }
 
+func displayLastSync(ctx context.Context, repo *repository.DatabaseRepository) {
+	runs, err := repo.GetRecentSyncRuns(ctx, 1)
+	if err != nil {
+		log.Fatalf("fetch last sync failed: %v", err)
+	}
+	if len(runs) == 0 {
+		fmt.Println("No syncs have run yet")
+		return
+	}
+	run := runs[0]
 
+	repoRuns, err := repo.GetSyncRunRepositories(ctx, run.ID)
+	if err != nil {
+		log.Fatalf("fetch last sync repositories failed: %v", err)
+	}
+
+	fmt.Printf("Sync #%d: %s\n", run.ID, run.Outcome)
+	fmt.Printf("  Started:   %s (%s ago)\n", run.StartedAt.Local().Format("2006-01-02 15:04:05"), time.Since(run.StartedAt).Round(time.Second))
+	fmt.Printf("  Duration:  %s\n", run.Duration().Round(time.Second))
+	fmt.Printf("  Changes:   %d new, %d updated, %d removed, %d stale\n", run.NewCount, run.UpdatedCount, run.RemovedCount, run.StaleCount)
+	fmt.Printf("  API calls: %d (%d cached, %d retried)\n", run.APICalls, run.CachedResponses, run.Retries)
+	if run.RateLimit > 0 {
+		fmt.Printf("  Quota:     %d of %d left, resets at %s\n", run.RateLimitRemaining, run.RateLimit, run.RateLimitReset.Local().Format("15:04"))
+	}
+	if run.Error != "" {
+		fmt.Printf("  Error:     %s\n", run.Error)
+	}
 
-func dispatchRepositoriesCommand(repo *repository.DatabaseRepository, args []string) {
+	if len(repoRuns) == 0 {
+		return
+	}
+	fmt.Println("Repositories:")
+	for _, repoRun := range repoRuns {
+		fmt.Printf("- %s: %s, %d new, %d updated, %d removed, %d stale (fetched in %s)\n",
+			repoRun.Repository,
+			repoRun.Outcome,
+			repoRun.NewCount,
+			repoRun.UpdatedCount,
+			repoRun.RemovedCount,
+			repoRun.StaleCount,
+			repoRun.Duration.Round(time.Millisecond),
+		)
+		if repoRun.Error != "" {
+			fmt.Printf("    error: %s\n", repoRun.Error)
+		}
+	}
+}
+
+func dispatchRepositoriesCommand(ctx context.Context, repo *repository.DatabaseRepository, args []string) {
 	if len(args) < 1 {
 		printUsage()
 		os.Exit(1)
@@ -190,14 +582,14 @@ func dispatchRepositoriesCommand(repo *repository.DatabaseRepository, args []str
 	switch args[0] {
 	case "list":
 		// Handle repositories list command
-		displayRepositories(repo)
+		displayRepositories(ctx, repo)
 	case "add":
 		// Handle repositories add command
-		addRepository(repo, args[1:])
+		addRepository(ctx, repo, args[1:])
 	case "remove":
 		// Handle repositories remove command
 		fmt.Println("Removing repository...")
-		deleteRepository(repo, args[1:])
+		deleteRepository(ctx, repo, args[1:])
 	default:
 		fmt.Printf("Unknown repositories command: %s\n", args[0])
 		printUsage()
@@ -205,7 +597,7 @@ func dispatchRepositoriesCommand(repo *repository.DatabaseRepository, args []str
 	}
 }
 
-func deleteRepository(repo *repository.DatabaseRepository, args []string) {
+func deleteRepository(ctx context.Context, repo *repository.DatabaseRepository, args []string) {
 	if len(args) < 1 {
 		fmt.Println("Repository name is required")
 		printUsage()
@@ -213,14 +605,14 @@ func deleteRepository(repo *repository.DatabaseRepository, args []string) {
 	}
 	repository := args[0]
 
-	if err := repo.DeleteTrackedRepository(repository); err != nil {
+	if err := repo.DeleteTrackedRepository(ctx, repository); err != nil {
 		log.Fatalf("delete repository failed: %v", err)
 	}
 	fmt.Printf("Repository '%s' deleted successfully\n", repository)
 }
 
-func displayRepositories(repo *repository.DatabaseRepository) {
-	repositories, err := repo.GetTrackedRepositories()
+func displayRepositories(ctx context.Context, repo *repository.DatabaseRepository) {
+	repositories, err := repo.GetTrackedRepositories(ctx)
 	if err != nil {
 		log.Fatalf("list repositories failed: %v", err)
 	}
@@ -231,7 +623,7 @@ func displayRepositories(repo *repository.DatabaseRepository) {
 	}
 }
 
-func addRepository(repo *repository.DatabaseRepository, args []string) {
+func addRepository(ctx context.Context, repo *repository.DatabaseRepository, args []string) {
 	if len(args) < 1 {
 		fmt.Println("Repository name is required")
 		printUsage()
@@ -239,14 +631,192 @@ func addRepository(repo *repository.DatabaseRepository, args []string) {
 	}
 	repository := args[0]
 
-	if err := repo.SaveTrackedRepository(repository); err != nil {
+	if err := repo.SaveTrackedRepository(ctx, repository); err != nil {
 		log.Fatalf("add repository failed: %v", err)
 	}
 	fmt.Printf("Repository '%s' added successfully\n", repository)
 }
 
 
-func dispatchAuthorsCommand(repo *repository.DatabaseRepository, args []string) {
+func dispatchHostsCommand(ctx context.Context, repo *repository.DatabaseRepository, args []string) {
+	if len(args) < 1 {
+		printUsage()
+		os.Exit(1)
+	}
+	switch args[0] {
+	case "list":
+		displayHosts(ctx, repo)
+	case "add":
+		addHost(ctx, repo, args[1:])
+	case "remove":
+		removeHost(ctx, repo, args[1:])
+	default:
+		fmt.Printf("Unknown hosts command: %s\n", args[0])
+		printUsage()
+		os.Exit(1)
+	}
+}
+
+func displayHosts(ctx context.Context, repo *repository.DatabaseRepository) {
+	hosts, err := repo.GetGitHubHosts(ctx)
+	if err != nil {
+		log.Fatalf("list hosts failed: %v", err)
+	}
+
+	fmt.Println("Hosts:")
+	for _, host := range hosts {
+		token := "own token"
+		if host.AccessToken == "" {
+			token = "your github.com token"
+		}
+		fmt.Printf("- %s: api %s, web %s, %s backend, %s\n", host.Host, host.APIURL, host.WebURL, host.Backend, token)
+		if host.ProxyURL != "" {
+			fmt.Printf("    proxy: %s\n", host.ProxyURL)
+		}
+		if host.CAFile != "" {
+			fmt.Printf("    ca file: %s\n", host.CAFile)
+		}
+	}
+}
+
+func addHost(ctx context.Context, repo *repository.DatabaseRepository, args []string) {
+	if len(args) < 1 {
+		fmt.Println("Host name is required")
+		printUsage()
+		os.Exit(1)
+	}
+	host := models.DefaultGitHubHost(args[0])
+
+	flags := flag.NewFlagSet("hosts add", flag.ExitOnError)
+	flags.StringVar(&host.APIURL, "api-url", host.APIURL, "REST API root")
+	flags.StringVar(&host.WebURL, "web-url", host.WebURL, "web interface root")
+	flags.StringVar(&host.AccessToken, "token", "", "access token for the host (github.com defaults to your authenticated token)")
+	flags.StringVar(&host.ProxyURL, "proxy", "", "HTTP proxy to reach the host through")
+	flags.StringVar(&host.CAFile, "ca-file", "", "PEM bundle to trust in addition to the system roots")
+	flags.StringVar(&host.Backend, "backend", host.Backend, "API to sync pull requests with: rest or graphql")
+	if err := flags.Parse(args[1:]); err != nil {
+		log.Fatalf("parse hosts add flags failed: %v", err)
+	}
+	if _, err := github.ParseBackend(host.Backend); err != nil {
+		log.Fatalf("invalid backend: %v", err)
+	}
+	if host.AccessToken == "" && host.Host != models.DefaultHost {
+		log.Fatalf("a token is required for %s, pass it with -token", host.Host)
+	}
+
+	user, err := repo.GetUser(ctx)
+	if err != nil {
+		log.Fatalf("fetch user failed: %v", err)
+	}
+	client, err := prsync.NewClient(host, user.AccessToken, github.Config{Cache: repo})
+	if err != nil {
+		log.Fatalf("create github client failed: %v", err)
+	}
+	hostUser, err := client.FetchAuthenticatedUser(ctx)
+	if err != nil {
+		log.Fatalf("check host credentials failed: %v", err)
+	}
+
+	if err := repo.SaveGitHubHost(ctx, host); err != nil {
+		log.Fatalf("add host failed: %v", err)
+	}
+	fmt.Printf("Host '%s' added, authenticated as %s\n", host.Host, hostUser.Login)
+}
+
+func removeHost(ctx context.Context, repo *repository.DatabaseRepository, args []string) {
+	if len(args) < 1 {
+		fmt.Println("Host name is required")
+		printUsage()
+		os.Exit(1)
+	}
+	host := args[0]
+
+	if err := repo.DeleteGitHubHost(ctx, host); err != nil {
+		log.Fatalf("remove host failed: %v", err)
+	}
+	fmt.Printf("Host '%s' removed\n", host)
+}
+
+// newHostClient builds a client for hostName using its stored settings, or
+// the defaults if it has none.
+func newHostClient(ctx context.Context, repo *repository.DatabaseRepository, hostName, token string) (*github.Client, error) {
+	hosts, err := repo.GetGitHubHosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	host := models.DefaultGitHubHost(hostName)
+	for _, configured := range hosts {
+		if configured.Host == hostName {
+			host = configured
+		}
+	}
+
+	return prsync.NewClient(host, token, github.Config{Cache: repo})
+}
+
+func dispatchCommentersCommand(ctx context.Context, repo *repository.DatabaseRepository, user *models.User, args []string) {
+	if len(args) < 1 {
+		printUsage()
+		os.Exit(1)
+	}
+	switch args[0] {
+	case "list":
+		displayIgnoredCommenters(ctx, repo, user)
+	case "ignore":
+		ignoreCommenter(ctx, repo, args[1:])
+	case "unignore":
+		unignoreCommenter(ctx, repo, args[1:])
+	default:
+		fmt.Printf("Unknown commenters command: %s\n", args[0])
+		printUsage()
+		os.Exit(1)
+	}
+}
+
+func displayIgnoredCommenters(ctx context.Context, repo *repository.DatabaseRepository, user *models.User) {
+	ignored, err := repo.GetIgnoredCommenters(ctx)
+	if err != nil {
+		log.Fatalf("list ignored commenters failed: %v", err)
+	}
+
+	fmt.Println("Ignored commenters:")
+	fmt.Printf("- %s (you)\n", user.Username)
+	fmt.Println("- any login ending in [bot]")
+	for _, login := range ignored {
+		fmt.Printf("- %s\n", login)
+	}
+}
+
+func ignoreCommenter(ctx context.Context, repo *repository.DatabaseRepository, args []string) {
+	if len(args) < 1 {
+		fmt.Println("Commenter login is required")
+		printUsage()
+		os.Exit(1)
+	}
+	login := args[0]
+
+	if err := repo.SaveIgnoredCommenter(ctx, login); err != nil {
+		log.Fatalf("ignore commenter failed: %v", err)
+	}
+	fmt.Printf("Comments by '%s' will no longer count as new\n", login)
+}
+
+func unignoreCommenter(ctx context.Context, repo *repository.DatabaseRepository, args []string) {
+	if len(args) < 1 {
+		fmt.Println("Commenter login is required")
+		printUsage()
+		os.Exit(1)
+	}
+	login := args[0]
+
+	if err := repo.DeleteIgnoredCommenter(ctx, login); err != nil {
+		log.Fatalf("unignore commenter failed: %v", err)
+	}
+	fmt.Printf("Comments by '%s' count as new again\n", login)
+}
+
+func dispatchAuthorsCommand(ctx context.Context, repo *repository.DatabaseRepository, args []string) {
 	if len(args) < 1 {
 		printUsage()
 		os.Exit(1)
@@ -254,10 +824,10 @@ func dispatchAuthorsCommand(repo *repository.DatabaseRepository, args []string)
 	switch args[0] {
 	case "list":
 		// Handle authors list command
-		displayAuthors(repo)
+		displayAuthors(ctx, repo)
 	case "add":
 		// Handle authors add command
-		addAuthor(repo, args[1:])
+		addAuthor(ctx, repo, args[1:])
 	case "remove":
 		// Handle authors remove command
 		fmt.Println("Removing author...")
@@ -268,8 +838,8 @@ func dispatchAuthorsCommand(repo *repository.DatabaseRepository, args []string)
 	}
 }
 
-func displayAuthors(repo *repository.DatabaseRepository) {
-	authors, err := repo.GetTrackedAuthors()
+func displayAuthors(ctx context.Context, repo *repository.DatabaseRepository) {
+	authors, err := repo.GetTrackedAuthors(ctx)
 	if err != nil {
 		log.Fatalf("list authors failed: %v", err)
 	}
@@ -280,7 +850,7 @@ func displayAuthors(repo *repository.DatabaseRepository) {
 	}
 }
 
-func addAuthor(repo *repository.DatabaseRepository, args []string) {
+func addAuthor(ctx context.Context, repo *repository.DatabaseRepository, args []string) {
 	if len(args) < 1 {
 		fmt.Println("Author login is required")
 		printUsage()
@@ -288,7 +858,7 @@ func addAuthor(repo *repository.DatabaseRepository, args []string) {
 	}
 	login := args[0]
 
-	if err := repo.SaveTrackedAuthor(login); err != nil {
+	if err := repo.SaveTrackedAuthor(ctx, login); err != nil {
 		log.Fatalf("add author failed: %v", err)
 	}
 	fmt.Printf("Author '%s' added successfully\n", login)
@@ -302,4 +872,19 @@ func printUsage() {
 	fmt.Println("  authors list    List authors")
 	fmt.Println("  authors add     Add author")
 	fmt.Println("  authors remove  Remove author")
+	fmt.Println("  prs             List tracked pull requests")
+	fmt.Println("                  [-state open|merged|closed] [-limit N]")
+	fmt.Println("  prs show        Show a pull request and its timeline, given as owner/repo#N")
+	fmt.Println("  commenters      Manage whose comments don't count as new: you and [bot] logins always,")
+	fmt.Println("                  plus any ignored here. list | ignore <login> | unignore <login>")
+	fmt.Println("  hosts list      List GitHub hosts")
+	fmt.Println("  hosts add       Add a GitHub Enterprise host, then track its repositories as host/owner/repo")
+	fmt.Println("                  <host> [-token T] [-api-url U] [-web-url U] [-proxy U] [-ca-file F] [-backend rest|graphql]")
+	fmt.Println("  hosts remove    Remove a GitHub host")
+	fmt.Println("  sync            Sync tracked repositories, skipping unchanged pull requests")
+	fmt.Println("                  [-parallelism N] [-full] [-record FILE | -replay FILE]")
+	fmt.Println("                  -replay syncs a throwaway copy of the database")
+	fmt.Println("  sync history    List recent syncs, optionally followed by how many")
+	fmt.Println("  sync last       Show the most recent sync in detail")
+	fmt.Println("  status          Show API quota used by the last sync and how much is left")
 }
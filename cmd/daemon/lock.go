@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// acquireLock takes an exclusive, non-blocking lock on path so that two
+// daemons never sync into the same database. The lock is released by the
+// kernel if the process dies, so a stale lock file never needs cleaning up.
+func acquireLock(path string) (*os.File, error) {
+	lockFile, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open lock file: %w", err)
+	}
+
+	if err := syscall.Flock(int(lockFile.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		lockFile.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, fmt.Errorf("another daemon already holds %s", path)
+		}
+		return nil, fmt.Errorf("lock %s: %w", path, err)
+	}
+
+	if err := lockFile.Truncate(0); err == nil {
+		_, _ = lockFile.WriteString(strconv.Itoa(os.Getpid()) + "\n")
+	}
+
+	return lockFile, nil
+}
+
+func releaseLock(lockFile *os.File) {
+	if err := syscall.Flock(int(lockFile.Fd()), syscall.LOCK_UN); err != nil {
+		log.Printf("release lock failed: %v", err)
+	}
+	if err := lockFile.Close(); err != nil {
+		log.Printf("close lock file failed: %v", err)
+	}
+}
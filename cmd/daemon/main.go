@@ -1,5 +1,156 @@
 package main
 
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"git.rileymathews.com/riley/pr-tracker/internal/db/repository"
+	"git.rileymathews.com/riley/pr-tracker/internal/github"
+	"git.rileymathews.com/riley/pr-tracker/internal/models"
+	"git.rileymathews.com/riley/pr-tracker/internal/service"
+	prsync "git.rileymathews.com/riley/pr-tracker/internal/sync"
+	"git.rileymathews.com/riley/pr-tracker/internal/webhook"
+	_ "modernc.org/sqlite"
+)
+
+const dbPath = "./db.sqlite3"
+
 func main() {
-	println("Hello, World!")
+	interval := flag.Duration("interval", 5*time.Minute, "time to wait between syncs")
+	parallelism := flag.Int("parallelism", service.DefaultParallelism, "maximum number of GitHub fetches in flight at once")
+	fullResyncInterval := flag.Duration("full-resync-interval", prsync.DefaultFullResyncInterval, "longest a pull request that looks unchanged goes without a full fetch")
+	webhookAddr := flag.String("webhook-addr", "", "address to receive GitHub webhooks on, such as :8080; the secret is read from "+webhookSecretEnv)
+	flag.Parse()
+
+	if *interval <= 0 {
+		log.Fatalf("interval must be greater than zero, got %s", *interval)
+	}
+
+	lock, err := acquireLock(dbPath + ".lock")
+	if err != nil {
+		log.Fatalf("acquire daemon lock failed: %v", err)
+	}
+	defer releaseLock(lock)
+
+	dbConn, err := sql.Open("sqlite", dbPath+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		log.Fatalf("open sqlite db failed: %v", err)
+	}
+	defer func() {
+		if closeErr := dbConn.Close(); closeErr != nil {
+			log.Printf("close sqlite db failed: %v", closeErr)
+		}
+	}()
+
+	// Shutdown cancels in-flight fetches. A repository whose writes have
+	// already started still commits; see prsync.Run.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := repository.ApplyMigrations(ctx, dbConn, "internal/db/migrations"); err != nil {
+		log.Fatalf("apply sqlite migrations failed: %v", err)
+	}
+
+	repo := repository.New(dbConn)
+	opts := prsync.Options{
+		Parallelism:        *parallelism,
+		FullResyncInterval: *fullResyncInterval,
+		GitHub:             github.Config{Cache: repo},
+	}
+
+	// Webhook events are applied from the sync loop below rather than the
+	// server's goroutines so they never write at the same time as a sync.
+	events := make(chan webhook.Event, webhookQueueSize)
+	if *webhookAddr != "" {
+		shutdown, err := startWebhookServer(*webhookAddr, os.Getenv(webhookSecretEnv), events)
+		if err != nil {
+			log.Fatalf("start webhook server failed: %v", err)
+		}
+		defer shutdown()
+	}
+
+	log.Printf("daemon started, syncing every %s", *interval)
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	for {
+		runSync(ctx, repo, opts)
+
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				log.Println("shutdown requested, exiting")
+				return
+			case event := <-events:
+				applyEvent(ctx, repo, event, opts)
+			case <-ticker.C:
+				break wait
+			}
+		}
+	}
+}
+
+func logChangeEvents(events []models.ChangeEvent) {
+	for _, event := range events {
+		log.Printf("%s#%d: %s", event.Repository, event.Number, event)
+	}
+}
+
+func runSync(ctx context.Context, repo *repository.DatabaseRepository, opts prsync.Options) {
+	// The user is looked up on every run so the daemon can be started
+	// before 'cli auth' has been run.
+	user, err := repo.GetUser(ctx)
+	if err != nil {
+		log.Printf("fetch user failed: %v", err)
+		return
+	}
+	if user == nil {
+		log.Println("no authenticated user found, please run 'cli auth <token>' to authenticate")
+		return
+	}
+
+	report, err := prsync.Run(ctx, repo, user.AccessToken, opts)
+	if err != nil {
+		log.Printf("sync stopped early: %v", err)
+	}
+
+	for _, repoReport := range report.Repositories {
+		if repoReport.Err != nil {
+			log.Printf("sync repository %s failed: %v", repoReport.Repository, repoReport.Err)
+			continue
+		}
+		for _, failure := range repoReport.FailedPrs {
+			log.Printf("sync pr %s#%d failed, keeping stale data: %v", repoReport.Repository, failure.Number, failure.Err)
+		}
+		logChangeEvents(repoReport.Events)
+	}
+
+	newCount, updatedCount, deletedCount := report.Totals()
+	log.Printf("synced %d repositories in %s using %d API calls (%d cached, %d retried): %d new, %d updated, %d archived, %d deleted, %d unchanged, %d stale",
+		len(report.Repositories),
+		report.Duration().Round(time.Millisecond),
+		report.APICalls,
+		report.CachedResponses,
+		report.Retries,
+		newCount,
+		updatedCount,
+		report.ArchivedCount(),
+		deletedCount,
+		report.UnchangedCount(),
+		report.StaleCount(),
+	)
+	if report.RateLimit.Known() {
+		log.Printf("api quota left: %d of %d, resets at %s",
+			report.RateLimit.Remaining,
+			report.RateLimit.Limit,
+			report.RateLimit.Reset.Local().Format(time.TimeOnly),
+		)
+	}
 }
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"git.rileymathews.com/riley/pr-tracker/internal/db/repository"
+	prsync "git.rileymathews.com/riley/pr-tracker/internal/sync"
+	"git.rileymathews.com/riley/pr-tracker/internal/webhook"
+)
+
+const (
+	// webhookSecretEnv names the environment variable holding the webhook
+	// secret, which is kept out of the command line so it doesn't show up in
+	// process listings.
+	webhookSecretEnv = "PR_TRACKER_WEBHOOK_SECRET"
+
+	// webhookQueueSize is how many events can wait while a sync is running.
+	// Events beyond that are dropped; the next sync picks up their changes.
+	webhookQueueSize = 256
+
+	webhookPath = "/webhook"
+)
+
+// startWebhookServer listens on addr and queues verified webhook events on
+// events. The returned function shuts the server down.
+func startWebhookServer(addr, secret string, events chan<- webhook.Event) (func(), error) {
+	handler, err := webhook.NewHandler(secret, func(event webhook.Event) {
+		select {
+		case events <- event:
+		default:
+			log.Printf("webhook queue full, dropping %s", event)
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w, set %s", err, webhookSecretEnv)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(webhookPath, handler)
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// Listening up front makes a port that is already taken fail startup
+	// instead of surfacing later from the server goroutine.
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listen on %s: %w", addr, err)
+	}
+
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("webhook server stopped: %v", err)
+		}
+	}()
+	log.Printf("receiving webhooks on %s%s", listener.Addr(), webhookPath)
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Printf("shut down webhook server failed: %v", err)
+		}
+	}, nil
+}
+
+func applyEvent(ctx context.Context, repo *repository.DatabaseRepository, event webhook.Event, opts prsync.Options) {
+	user, err := repo.GetUser(ctx)
+	if err != nil {
+		log.Printf("fetch user failed: %v", err)
+		return
+	}
+	if user == nil {
+		log.Printf("ignoring %s, no authenticated user", event)
+		return
+	}
+
+	repoReport, err := prsync.ApplyEvent(ctx, repo, user.AccessToken, event, opts)
+	if err != nil {
+		log.Printf("apply %s failed: %v", event, err)
+		return
+	}
+	if repoReport == nil {
+		return
+	}
+	if repoReport.Err != nil {
+		log.Printf("apply %s failed: %v", event, repoReport.Err)
+		return
+	}
+	for _, failure := range repoReport.FailedPrs {
+		log.Printf("apply %s: pr #%d failed, keeping stale data: %v", event, failure.Number, failure.Err)
+	}
+	logChangeEvents(repoReport.Events)
+
+	log.Printf("applied %s: %d new, %d updated, %d archived, %d deleted",
+		event,
+		len(repoReport.NewPrs),
+		len(repoReport.UpdatedPrs),
+		len(repoReport.ArchivedPrs),
+		len(repoReport.DeletedPrs),
+	)
+}
@@ -3,37 +3,126 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"os/exec"
+	"strings"
+	"sync"
+	"time"
 
 	tea "charm.land/bubbletea/v2"
-	"git.rileymathews.com/riley/pr-tracker/internal/db/gen"
 	"git.rileymathews.com/riley/pr-tracker/internal/db/repository"
+	"git.rileymathews.com/riley/pr-tracker/internal/github"
 	"git.rileymathews.com/riley/pr-tracker/internal/models"
+	prsync "git.rileymathews.com/riley/pr-tracker/internal/sync"
 	_ "modernc.org/sqlite"
 )
 
 type model struct {
 	prs []*models.PullRequest
 	cursor int
+
+	// events holds each pull request's event log, keyed by its reference.
+	// detail is set while the selected pull request's detail screen is
+	// shown instead of the list.
+	events map[string][]models.ChangeEvent
+	detail bool
+
+	// ctx is cancelled when the TUI exits, stopping any sync in flight.
+	ctx     context.Context
+	repo    *repository.DatabaseRepository
+	user    *models.User
+	syncing bool
+	status  string
+
+	// cancelSync stops the sync in flight, and syncs lets main wait for it
+	// to finish writing before the database is closed.
+	cancelSync context.CancelFunc
+	syncs      *sync.WaitGroup
+}
+
+type syncFinishedMsg struct {
+	report *prsync.SyncReport
+	prs    []*models.PullRequest
+	events map[string][]models.ChangeEvent
+	err    error
 }
 
-func initialModel(prs []*models.PullRequest) model {
+func initialModel(ctx context.Context, repo *repository.DatabaseRepository, user *models.User, prs []*models.PullRequest, events map[string][]models.ChangeEvent) model {
 	
 	return model{
 		prs: prs,
+		events: events,
 		cursor: 0,
+		ctx: ctx,
+		repo: repo,
+		user: user,
+		syncs: &sync.WaitGroup{},
+	}
+}
+
+func runSync(ctx context.Context, syncs *sync.WaitGroup, repo *repository.DatabaseRepository, token string) tea.Cmd {
+	return func() tea.Msg {
+		defer syncs.Done()
+
+		report, err := prsync.Run(ctx, repo, token, prsync.Options{GitHub: github.Config{Cache: repo}})
+		if err != nil {
+			return syncFinishedMsg{report: report, err: err}
+		}
+
+		prs, events, err := loadPullRequests(ctx, repo)
+		return syncFinishedMsg{report: report, prs: prs, events: events, err: err}
 	}
 }
 
+// loadPullRequests reads the tracked pull requests and their event logs.
+func loadPullRequests(ctx context.Context, repo *repository.DatabaseRepository) ([]*models.PullRequest, map[string][]models.ChangeEvent, error) {
+	prs, err := repo.GetAllPrs(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	events := make(map[string][]models.ChangeEvent, len(prs))
+	for _, pr := range prs {
+		prEvents, err := repo.GetPrEvents(ctx, pr.Repository, pr.Number)
+		if err != nil {
+			return nil, nil, fmt.Errorf("fetch events for %s: %w", pr.Reference(), err)
+		}
+		events[pr.Reference()] = prEvents
+	}
+
+	return prs, events, nil
+}
+
 func (m model) Init() tea.Cmd {
 	return nil
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+		case syncFinishedMsg:
+			m.syncing = false
+			m.cancelSync()
+			if errors.Is(msg.err, context.Canceled) {
+				m.status = "Sync cancelled"
+				break
+			}
+			if msg.err != nil {
+				m.status = fmt.Sprintf("Sync failed: %v", msg.err)
+				break
+			}
+
+			m.prs = msg.prs
+			m.events = msg.events
+			if m.cursor > len(m.prs)-1 {
+				m.cursor = max(len(m.prs)-1, 0)
+			}
+			newCount, updatedCount, deletedCount := msg.report.Totals()
+			m.status = fmt.Sprintf("Synced in %s: %d new, %d updated, %d archived, %d deleted, %d stale, %d failed",
+				msg.report.Duration().Round(time.Millisecond), newCount, updatedCount, msg.report.ArchivedCount(), deletedCount, msg.report.StaleCount(), len(msg.report.Failed()))
+
 		case tea.KeyPressMsg:
 			switch msg.String() {
 				case "ctrl+c", "q":
@@ -48,6 +137,39 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						m.cursor++
 					}
 
+				case "s":
+					if m.syncing {
+						break
+					}
+					if m.user == nil {
+						m.status = "No authenticated user found, please run 'cli auth <token>' first"
+						break
+					}
+
+					var syncCtx context.Context
+					syncCtx, m.cancelSync = context.WithCancel(m.ctx)
+					m.syncs.Add(1)
+					m.syncing = true
+					m.status = "Syncing..."
+					return m, runSync(syncCtx, m.syncs, m.repo, m.user.AccessToken)
+
+				case "d", "right", "l":
+					if len(m.prs) == 0 {
+						break
+					}
+					m.detail = true
+
+				case "esc", "left", "h":
+					m.detail = false
+
+				case "x":
+					if !m.syncing {
+						break
+					}
+
+					m.cancelSync()
+					m.status = "Cancelling sync..."
+
 				case "enter", "space":
 					if len(m.prs) == 0 {
 						break
@@ -65,6 +187,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m model) View() tea.View {
+	if m.detail && len(m.prs) > 0 {
+		return tea.NewView(m.detailView(m.prs[m.cursor]))
+	}
+
 	s := "What should we buy at the market?\n\n"
 
 	for i, choice := range m.prs {
@@ -73,16 +199,83 @@ func (m model) View() tea.View {
 			cursor = ">"
 		}
 
-		s += fmt.Sprintf("%s %s\n%s\n\n", cursor, choice.DisplayString(), choice.UpdatesSinceLastAck())
+		s += fmt.Sprintf("%s %s\n%s\n", cursor, choice.DisplayString(), choice.UpdatesSinceLastAck(m.events[choice.Reference()]))
+		if failing := choice.ChecksWithOutcome(models.CheckOutcomeFailing); len(failing) > 0 {
+			names := make([]string, 0, len(failing))
+			for _, check := range failing {
+				names = append(names, check.Name)
+			}
+			s += fmt.Sprintf("  Failing: %s\n", strings.Join(names, ", "))
+		}
+		if note := choice.MergeNote(); note != "" {
+			s += fmt.Sprintf("  %s\n", note)
+		}
+		if note := choice.StalenessNote(); note != "" {
+			s += fmt.Sprintf("  ! %s\n", note)
+		}
+		s += "\n"
+	}
+
+	if m.status != "" {
+		s += "\n " + m.status + "\n"
 	}
 
-	s += "\n Press q to quit.\n"
+	s += "\n Press s to sync, x to cancel a sync, d for details, q to quit.\n"
 
 	return tea.NewView(s)
 }
 
+// detailView shows a pull request's current state and its timeline, oldest
+// event first, like 'cli prs show'.
+func (m model) detailView(pr *models.PullRequest) string {
+	s := fmt.Sprintf("%s: %s\n\n", pr.Reference(), pr.Title)
+	s += fmt.Sprintf("  Author:  %s\n", pr.Author)
+	if pr.Draft {
+		s += "  State:   Draft\n"
+	} else {
+		s += "  State:   Open\n"
+	}
+	s += fmt.Sprintf("  CI:      %s\n", pr.CiStatus)
+	for _, outcome := range []models.CheckOutcome{models.CheckOutcomeFailing, models.CheckOutcomePending} {
+		for _, check := range pr.ChecksWithOutcome(outcome) {
+			label := check.Label()
+			if check.Required {
+				label += " [required]"
+			}
+			s += fmt.Sprintf("           %-7s %s  %s\n", outcome, label, check.DetailsURL)
+		}
+	}
+	if note := pr.MergeNote(); note != "" {
+		s += fmt.Sprintf("  Merge:   %s\n", note)
+	}
+	s += fmt.Sprintf("  Review:  %s\n", pr.ReviewDecision)
+	if len(pr.RequestedReviewers) > 0 {
+		s += fmt.Sprintf("  Waiting: %s\n", strings.Join(pr.RequestedReviewers, ", "))
+	}
+	if note := pr.StalenessNote(); note != "" {
+		s += fmt.Sprintf("  ! %s\n", note)
+	}
+
+	s += "\n Timeline\n"
+	events := m.events[pr.Reference()]
+	if len(events) == 0 {
+		s += "  No changes recorded yet\n"
+	}
+	for _, event := range events {
+		s += fmt.Sprintf("  %s  %s\n", event.At.Local().Format("2006-01-02 15:04"), event)
+	}
+
+	if m.status != "" {
+		s += "\n " + m.status + "\n"
+	}
+
+	s += "\n Press esc to go back, enter to open in the browser, q to quit.\n"
+
+	return s
+}
+
 func main() {
-	dbConn, err := sql.Open("sqlite", "./db.sqlite3")
+	dbConn, err := sql.Open("sqlite", "./db.sqlite3?_pragma=busy_timeout(5000)")
 	if err != nil {
 		log.Fatalf("open sqlite db failed: %v", err)
 	}
@@ -92,21 +285,35 @@ func main() {
 		}
 	}()
 
-	ctx := context.Background()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	if err := repository.ApplyMigrations(ctx, dbConn, "internal/db/migrations"); err != nil {
 		log.Fatalf("apply sqlite migrations failed: %v", err)
 	}
 
-	queries := gen.New(dbConn)
-	repo := repository.New(queries, ctx)
+	repo := repository.New(dbConn)
 
-	prs, err := repo.GetAllPrs()
+	prs, events, err := loadPullRequests(ctx, repo)
 	if err != nil {
 		log.Fatalf("could not fetch PRs %v", err)
 	}
 
-	p := tea.NewProgram(initialModel(prs))
-	if _, err := p.Run(); err != nil {
+	user, err := repo.GetUser(ctx)
+	if err != nil {
+		log.Fatalf("fetch user failed: %v", err)
+	}
+
+	m := initialModel(ctx, repo, user, prs, events)
+	p := tea.NewProgram(m)
+	_, err = p.Run()
+
+	// Quitting mid-sync cancels it; wait for it to wind down so the run is
+	// recorded before the database is closed.
+	cancel()
+	m.syncs.Wait()
+
+	if err != nil {
 		fmt.Printf("Alas there's been an error: %v", err)
 		os.Exit(1)
 	}
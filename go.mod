@@ -2,10 +2,12 @@ module git.rileymathews.com/riley/pr-tracker
 
 go 1.25.7
 
-require modernc.org/sqlite v1.38.2
+require (
+	charm.land/bubbletea/v2 v2.0.0
+	modernc.org/sqlite v1.38.2
+)
 
 require (
-	charm.land/bubbletea/v2 v2.0.0 // indirect
 	github.com/charmbracelet/colorprofile v0.4.1 // indirect
 	github.com/charmbracelet/ultraviolet v0.0.0-20260205113103-524a6607adb8 // indirect
 	github.com/charmbracelet/x/ansi v0.11.6 // indirect
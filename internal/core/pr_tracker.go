@@ -1,6 +1,7 @@
 package core
 
 import (
+	"slices"
 	"strconv"
 	"time"
 
@@ -8,7 +9,10 @@ import (
 )
 
 
-func ProcessPullRequestSyncResults(prsFromDatabase, prsFromFreshSync []*models.PullRequest) (newPrs, updatedPrs, removedPrs []*models.PullRequest) {
+// ProcessPullRequestSyncResults compares the stored pull requests with a fresh
+// sync. A pull request is updated when at least one change event was found for
+// it; events lists them for every new and updated pull request, in order.
+func ProcessPullRequestSyncResults(prsFromDatabase, prsFromFreshSync []*models.PullRequest) (newPrs, updatedPrs, removedPrs []*models.PullRequest, events []models.ChangeEvent) {
 	dbByKey := indexPullRequestsByKey(prsFromDatabase)
 	seen := make(map[string]struct{}, len(prsFromFreshSync))
 	now := time.Now().UTC()
@@ -24,21 +28,50 @@ func ProcessPullRequestSyncResults(prsFromDatabase, prsFromFreshSync []*models.P
 		existingPr, exists := dbByKey[key]
 		if !exists {
 			newPrs = append(newPrs, incomingPr)
+			events = append(events, changeEvent(incomingPr, models.ChangeEventOpened, incomingPr.Author, incomingPr.CreatedAt))
 			continue
 		}
 
-		ciStatusChanged, hasRelevantChanges := pullRequestHasRelevantChanges(existingPr, incomingPr)
-		if !hasRelevantChanges {
+		prEvents := pullRequestChangeEvents(existingPr, incomingPr, now)
+		if len(prEvents) == 0 {
 			continue
 		}
 
+		ciStatusChanged := slices.ContainsFunc(prEvents, func(event models.ChangeEvent) bool {
+			return event.Kind == models.ChangeEventCiStatusChanged
+		})
 		applySyncMetadata(existingPr, incomingPr, ciStatusChanged, now)
 		updatedPrs = append(updatedPrs, incomingPr)
+		events = append(events, prEvents...)
 	}
 
 	removedPrs = collectRemovedPullRequests(dbByKey, seen)
 
-	return newPrs, updatedPrs, removedPrs
+	return newPrs, updatedPrs, removedPrs, events
+}
+
+// ArchiveClosedPullRequests splits the pull requests removed by a sync into
+// those whose final state is among closed, which are archived with a merged
+// or closed event, and the rest, which are deleted.
+func ArchiveClosedPullRequests(removedPrs, closedPrs []*models.PullRequest) (archivedPrs, deletedPrs []*models.PullRequest, events []models.ChangeEvent) {
+	closedByKey := indexPullRequestsByKey(closedPrs)
+
+	for _, removedPr := range removedPrs {
+		closedPr, ok := closedByKey[pullRequestKey(removedPr)]
+		if !ok {
+			deletedPrs = append(deletedPrs, removedPr)
+			continue
+		}
+
+		archivedPrs = append(archivedPrs, closedPr)
+		if closedPr.State == models.PullRequestStateMerged {
+			events = append(events, changeEvent(closedPr, models.ChangeEventMerged, closedPr.MergedBy, closedPr.MergedAt))
+		} else {
+			events = append(events, changeEvent(closedPr, models.ChangeEventClosed, "", closedPr.ClosedAt))
+		}
+	}
+
+	return archivedPrs, deletedPrs, events
 }
 
 func pullRequestKey(pr *models.PullRequest) string {
@@ -56,13 +89,165 @@ func indexPullRequestsByKey(prs []*models.PullRequest) map[string]*models.PullRe
 	return byKey
 }
 
-func pullRequestHasRelevantChanges(existingPr, incomingPr *models.PullRequest) (ciStatusChanged, hasRelevantChanges bool) {
-	ciStatusChanged = existingPr.CiStatus != incomingPr.CiStatus
-	lastCommentChanged := !existingPr.LastCommentAt.Equal(incomingPr.LastCommentAt)
-	lastCommitChanged := !existingPr.LastCommitAt.Equal(incomingPr.LastCommitAt)
+// pullRequestChangeEvents lists what changed between the stored and the
+// freshly synced copy of a pull request. now is used for changes GitHub does
+// not timestamp.
+func pullRequestChangeEvents(existingPr, incomingPr *models.PullRequest, now time.Time) []models.ChangeEvent {
+	var events []models.ChangeEvent
+
+	if incomingPr.LastCommentAt.After(existingPr.LastCommentAt) {
+		events = append(events, changeEvent(incomingPr, models.ChangeEventNewComment, incomingPr.LastCommentAuthor, incomingPr.LastCommentAt))
+	}
+
+	// Rows stored before head commits were tracked have no SHA to compare
+	// against, so the first sync after upgrading doesn't flag every pull
+	// request.
+	if existingPr.HeadSHA != "" && incomingPr.HeadSHA != "" && existingPr.HeadSHA != incomingPr.HeadSHA {
+		// The head commit's date is when it was committed, not pushed, so
+		// an older commit becoming the head is dated by the pull request's
+		// update instead.
+		at := incomingPr.LastCommitAt
+		if !at.After(existingPr.LastCommitAt) {
+			at = incomingPr.UpdatedAt
+		}
+		event := changeEvent(incomingPr, headUpdateKind(incomingPr.HeadUpdate), incomingPr.HeadCommitAuthor, at)
+		event.From, event.To = existingPr.HeadSHA, incomingPr.HeadSHA
+		event.Count = incomingPr.NewCommitCount
+		events = append(events, event)
+	}
+
+	if existingPr.CiStatus != incomingPr.CiStatus {
+		event := changeEvent(incomingPr, models.ChangeEventCiStatusChanged, "", now)
+		event.From, event.To = existingPr.CiStatus.String(), incomingPr.CiStatus.String()
+		events = append(events, event)
+	}
+	events = append(events, checkChangeEvents(existingPr, incomingPr, now)...)
+
+	// GitHub works mergeability out in the background without a timestamp,
+	// so these are dated by the sync that noticed them.
+	if incomingPr.HasConflicts() && !existingPr.HasConflicts() {
+		event := changeEvent(incomingPr, models.ChangeEventConflicts, "", now)
+		event.Subject = incomingPr.BaseRef
+		events = append(events, event)
+	}
+
+	if incomingPr.NeedsUpdate() && !existingPr.NeedsUpdate() {
+		event := changeEvent(incomingPr, models.ChangeEventNeedsUpdate, "", now)
+		event.Subject, event.Count = incomingPr.BaseRef, incomingPr.BehindBy
+		events = append(events, event)
+	}
+
+	if existingPr.Title != incomingPr.Title {
+		event := changeEvent(incomingPr, models.ChangeEventTitleChanged, "", incomingPr.UpdatedAt)
+		event.From, event.To = existingPr.Title, incomingPr.Title
+		events = append(events, event)
+	}
+
+	if existingPr.Draft != incomingPr.Draft {
+		event := changeEvent(incomingPr, models.ChangeEventDraftChanged, "", incomingPr.UpdatedAt)
+		event.From, event.To = strconv.FormatBool(existingPr.Draft), strconv.FormatBool(incomingPr.Draft)
+		events = append(events, event)
+	}
+
+	reviewed := map[string]bool{}
+	for _, review := range incomingPr.Reviews {
+		previous, ok := findReview(existingPr.Reviews, review.Reviewer)
+		if ok && previous.State == review.State && previous.SubmittedAt.Equal(review.SubmittedAt) {
+			continue
+		}
+
+		reviewed[review.Reviewer] = true
+		event := changeEvent(incomingPr, models.ChangeEventReviewed, review.Reviewer, review.SubmittedAt)
+		event.From, event.To = string(previous.State), string(review.State)
+		events = append(events, event)
+	}
+
+	for _, reviewer := range incomingPr.RequestedReviewers {
+		if !slices.Contains(existingPr.RequestedReviewers, reviewer) {
+			event := changeEvent(incomingPr, models.ChangeEventReviewerAdded, "", incomingPr.UpdatedAt)
+			event.To = reviewer
+			events = append(events, event)
+		}
+	}
+
+	// GitHub drops a reviewer from the requested reviewers once they submit
+	// a review, which the review event already covers.
+	for _, reviewer := range existingPr.RequestedReviewers {
+		if !slices.Contains(incomingPr.RequestedReviewers, reviewer) && !reviewed[reviewer] {
+			event := changeEvent(incomingPr, models.ChangeEventReviewerRemoved, "", incomingPr.UpdatedAt)
+			event.To = reviewer
+			events = append(events, event)
+		}
+	}
+
+	if existingPr.ReviewDecision != incomingPr.ReviewDecision {
+		event := changeEvent(incomingPr, models.ChangeEventReviewDecision, "", incomingPr.UpdatedAt)
+		event.From, event.To = string(existingPr.ReviewDecision), string(incomingPr.ReviewDecision)
+		events = append(events, event)
+	}
+
+	return events
+}
+
+// headUpdateKind picks the event for a moved head. A head whose move couldn't
+// be classified is reported as new commits.
+func headUpdateKind(update models.HeadUpdate) models.ChangeEventKind {
+	switch update {
+	case models.HeadUpdateRebased:
+		return models.ChangeEventRebased
+	case models.HeadUpdateRewritten:
+		return models.ChangeEventForcePushed
+	default:
+		return models.ChangeEventNewCommits
+	}
+}
+
+// checkChangeEvents reports each check that started failing or recovered from
+// failing. Checks starting, or finishing without having failed, are left to
+// the overall CI status so a push doesn't report every check twice.
+func checkChangeEvents(existingPr, incomingPr *models.PullRequest, now time.Time) []models.ChangeEvent {
+	previous := make(map[string]models.CheckOutcome, len(existingPr.Checks))
+	for _, check := range existingPr.Checks {
+		previous[check.Key()] = check.Outcome()
+	}
+
+	var events []models.ChangeEvent
+	for _, check := range incomingPr.Checks {
+		from, outcome := previous[check.Key()], check.Outcome()
+		failed := outcome == models.CheckOutcomeFailing && from != models.CheckOutcomeFailing
+		recovered := outcome == models.CheckOutcomePassing && from == models.CheckOutcomeFailing
+		if !failed && !recovered {
+			continue
+		}
+
+		at := check.CompletedAt
+		if at.IsZero() {
+			at = now
+		}
+		event := changeEvent(incomingPr, models.ChangeEventCheckChanged, "", at)
+		event.From, event.To, event.Subject = string(from), string(outcome), check.Name
+		events = append(events, event)
+	}
+	return events
+}
+
+func changeEvent(pr *models.PullRequest, kind models.ChangeEventKind, actor string, at time.Time) models.ChangeEvent {
+	return models.ChangeEvent{
+		Repository: pr.Repository,
+		Number:     pr.Number,
+		Kind:       kind,
+		Actor:      actor,
+		At:         at,
+	}
+}
 
-	hasRelevantChanges = ciStatusChanged || lastCommentChanged || lastCommitChanged
-	return ciStatusChanged, hasRelevantChanges
+func findReview(reviews []models.Review, reviewer string) (models.Review, bool) {
+	for _, review := range reviews {
+		if review.Reviewer == reviewer {
+			return review, true
+		}
+	}
+	return models.Review{}, false
 }
 
 func applySyncMetadata(existingPr, incomingPr *models.PullRequest, ciStatusChanged bool, now time.Time) {
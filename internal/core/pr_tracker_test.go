@@ -1,6 +1,7 @@
 package core
 
 import (
+	"slices"
 	"testing"
 	"time"
 
@@ -20,7 +21,7 @@ func newPR(repo string, number int) *models.PullRequest {
 func TestProcessPullRequestSyncResults_NewPR(t *testing.T) {
 	pr := newPR("acme/repo", 1)
 
-	newPrs, updatedPrs, removedPrs := ProcessPullRequestSyncResults(
+	newPrs, updatedPrs, removedPrs, _ := ProcessPullRequestSyncResults(
 		nil,
 		[]*models.PullRequest{pr},
 	)
@@ -56,7 +57,7 @@ func TestProcessPullRequestSyncResults_UpdatedPR(t *testing.T) {
 		LastCommitAt:  base,
 	}
 
-	newPrs, updatedPrs, removedPrs := ProcessPullRequestSyncResults(
+	newPrs, updatedPrs, removedPrs, _ := ProcessPullRequestSyncResults(
 		[]*models.PullRequest{dbPR},
 		[]*models.PullRequest{freshPR},
 	)
@@ -72,12 +73,53 @@ func TestProcessPullRequestSyncResults_UpdatedPR(t *testing.T) {
 	}
 }
 
+// TestProcessPullRequestSyncResults_ReviewedPR verifies that a new review, or
+// a change in the review decision, counts as a relevant change.
+func TestProcessPullRequestSyncResults_ReviewedPR(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	dbPR := &models.PullRequest{
+		Repository:     "acme/repo",
+		Number:         1,
+		Reviews:        []models.Review{{Reviewer: "carol", State: models.ReviewStateCommented, SubmittedAt: base}},
+		ReviewDecision: models.ReviewDecisionReviewRequired,
+	}
+	unchangedPR := &models.PullRequest{
+		Repository:     "acme/repo",
+		Number:         1,
+		Reviews:        []models.Review{{Reviewer: "carol", State: models.ReviewStateCommented, SubmittedAt: base.In(time.Local)}},
+		ReviewDecision: models.ReviewDecisionReviewRequired,
+	}
+	approvedPR := &models.PullRequest{
+		Repository:     "acme/repo",
+		Number:         1,
+		Reviews:        []models.Review{{Reviewer: "carol", State: models.ReviewStateApproved, SubmittedAt: base.Add(time.Hour)}},
+		ReviewDecision: models.ReviewDecisionApproved,
+	}
+
+	_, updatedPrs, _, _ := ProcessPullRequestSyncResults(
+		[]*models.PullRequest{dbPR},
+		[]*models.PullRequest{unchangedPR},
+	)
+	if len(updatedPrs) != 0 {
+		t.Errorf("expected 0 updated PRs for the same review, got %d", len(updatedPrs))
+	}
+
+	_, updatedPrs, _, _ = ProcessPullRequestSyncResults(
+		[]*models.PullRequest{dbPR},
+		[]*models.PullRequest{approvedPR},
+	)
+	if len(updatedPrs) != 1 || updatedPrs[0] != approvedPR {
+		t.Errorf("expected 1 updated PR after approval, got %d", len(updatedPrs))
+	}
+}
+
 // TestProcessPullRequestSyncResults_RemovedPR verifies that a PR present in
 // the database but absent from the fresh sync is classified as removed.
 func TestProcessPullRequestSyncResults_RemovedPR(t *testing.T) {
 	pr := newPR("acme/repo", 1)
 
-	newPrs, updatedPrs, removedPrs := ProcessPullRequestSyncResults(
+	newPrs, updatedPrs, removedPrs, _ := ProcessPullRequestSyncResults(
 		[]*models.PullRequest{pr},
 		nil,
 	)
@@ -110,7 +152,7 @@ func TestProcessPullRequestSyncResults_Mixed(t *testing.T) {
 	// --- database state ---
 	db1 := &models.PullRequest{Repository: "org/repo", Number: 1,
 		CiStatus: models.CiStatusPending, LastCommentAt: base, LastCommitAt: base}
-	db2 := &models.PullRequest{Repository: "org/repo", Number: 2,
+	db2 := &models.PullRequest{Repository: "org/repo", Number: 2, HeadSHA: "aaa",
 		CiStatus: models.CiStatusPending, LastCommentAt: base, LastCommitAt: base}
 	db3 := &models.PullRequest{Repository: "org/repo", Number: 3,
 		CiStatus: models.CiStatusPending, LastCommentAt: base, LastCommitAt: base}
@@ -122,7 +164,7 @@ func TestProcessPullRequestSyncResults_Mixed(t *testing.T) {
 	// --- fresh sync ---
 	fresh1 := &models.PullRequest{Repository: "org/repo", Number: 1,
 		CiStatus: models.CiStatusPending, LastCommentAt: base, LastCommitAt: base} // unchanged
-	fresh2 := &models.PullRequest{Repository: "org/repo", Number: 2,
+	fresh2 := &models.PullRequest{Repository: "org/repo", Number: 2, HeadSHA: "bbb",
 		CiStatus: models.CiStatusPending, LastCommentAt: base, LastCommitAt: later} // new commit
 	fresh3 := &models.PullRequest{Repository: "org/repo", Number: 3,
 		CiStatus: models.CiStatusPending, LastCommentAt: later, LastCommitAt: base} // new comment
@@ -134,7 +176,7 @@ func TestProcessPullRequestSyncResults_Mixed(t *testing.T) {
 	dbPRs := []*models.PullRequest{db1, db2, db3, db4, db6}
 	freshPRs := []*models.PullRequest{fresh1, fresh2, fresh3, fresh4, fresh5}
 
-	newPrs, updatedPrs, removedPrs := ProcessPullRequestSyncResults(dbPRs, freshPRs)
+	newPrs, updatedPrs, removedPrs, _ := ProcessPullRequestSyncResults(dbPRs, freshPRs)
 
 	// --- new ---
 	if len(newPrs) != 1 {
@@ -173,3 +215,296 @@ func TestProcessPullRequestSyncResults_Mixed(t *testing.T) {
 		t.Errorf("expected removed PR #6, got #%d", removedPrs[0].Number)
 	}
 }
+
+// TestProcessPullRequestSyncResults_ChangeEvents verifies the events reported
+// for a new pull request and for one where several things changed at once.
+func TestProcessPullRequestSyncResults_ChangeEvents(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := base.Add(time.Hour)
+
+	dbPR := &models.PullRequest{
+		Repository:         "org/repo",
+		Number:             1,
+		Title:              "WIP cache",
+		Draft:              true,
+		CiStatus:           models.CiStatusPending,
+		LastCommentAt:      base,
+		LastCommitAt:       base,
+		RequestedReviewers: []string{"carol", "dave"},
+	}
+	freshPR := &models.PullRequest{
+		Repository:         "org/repo",
+		Number:             1,
+		Title:              "Cache widget lookups",
+		CiStatus:           models.CiStatusFailure,
+		UpdatedAt:          later,
+		LastCommentAt:      later,
+		LastCommitAt:       base,
+		RequestedReviewers: []string{"dave", "erin"},
+		Reviews:            []models.Review{{Reviewer: "carol", State: models.ReviewStateApproved, SubmittedAt: later}},
+		ReviewDecision:     models.ReviewDecisionApproved,
+	}
+	newPR := &models.PullRequest{Repository: "org/repo", Number: 2, Author: "alice", CreatedAt: base}
+
+	_, _, _, events := ProcessPullRequestSyncResults(
+		[]*models.PullRequest{dbPR},
+		[]*models.PullRequest{freshPR, newPR},
+	)
+
+	want := []models.ChangeEvent{
+		{Repository: "org/repo", Number: 1, Kind: models.ChangeEventNewComment, At: later},
+		{Repository: "org/repo", Number: 1, Kind: models.ChangeEventCiStatusChanged, From: "Pending", To: "Failure"},
+		{Repository: "org/repo", Number: 1, Kind: models.ChangeEventTitleChanged, At: later, From: "WIP cache", To: "Cache widget lookups"},
+		{Repository: "org/repo", Number: 1, Kind: models.ChangeEventDraftChanged, At: later, From: "true", To: "false"},
+		{Repository: "org/repo", Number: 1, Kind: models.ChangeEventReviewed, Actor: "carol", At: later, To: "APPROVED"},
+		{Repository: "org/repo", Number: 1, Kind: models.ChangeEventReviewerAdded, At: later, To: "erin"},
+		{Repository: "org/repo", Number: 1, Kind: models.ChangeEventReviewDecision, At: later, To: "APPROVED"},
+		{Repository: "org/repo", Number: 2, Kind: models.ChangeEventOpened, Actor: "alice", At: base},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %+v", len(want), events)
+	}
+	for i, event := range events {
+		// CI changes are stamped with the time of the sync.
+		if event.Kind == models.ChangeEventCiStatusChanged {
+			event.At = time.Time{}
+		}
+		if event != want[i] {
+			t.Errorf("event %d: expected %+v, got %+v", i, want[i], event)
+		}
+	}
+}
+
+// TestProcessPullRequestSyncResults_NewCommits verifies that new commits are
+// detected from the head SHA rather than commit or CI timestamps.
+func TestProcessPullRequestSyncResults_NewCommits(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	pushedAt := base.Add(2 * time.Hour)
+
+	tests := []struct {
+		name     string
+		existing *models.PullRequest
+		incoming *models.PullRequest
+		want     []models.ChangeEvent
+	}{
+		{
+			name:     "same head, later ci",
+			existing: &models.PullRequest{HeadSHA: "aaa", LastCommitAt: base},
+			incoming: &models.PullRequest{HeadSHA: "aaa", LastCommitAt: base, UpdatedAt: pushedAt},
+		},
+		{
+			name:     "new head",
+			existing: &models.PullRequest{HeadSHA: "aaa", LastCommitAt: base},
+			incoming: &models.PullRequest{HeadSHA: "bbb", HeadCommitAuthor: "alice", LastCommitAt: base.Add(time.Hour), UpdatedAt: pushedAt},
+			want:     []models.ChangeEvent{{Kind: models.ChangeEventNewCommits, Actor: "alice", At: base.Add(time.Hour), From: "aaa", To: "bbb"}},
+		},
+		{
+			name:     "older commit pushed",
+			existing: &models.PullRequest{HeadSHA: "aaa", LastCommitAt: base},
+			incoming: &models.PullRequest{HeadSHA: "ccc", LastCommitAt: base.Add(-time.Hour), UpdatedAt: pushedAt},
+			want:     []models.ChangeEvent{{Kind: models.ChangeEventNewCommits, At: pushedAt, From: "aaa", To: "ccc"}},
+		},
+		{
+			name:     "fast-forward",
+			existing: &models.PullRequest{HeadSHA: "aaa", LastCommitAt: base},
+			incoming: &models.PullRequest{HeadSHA: "bbb", HeadUpdate: models.HeadUpdateFastForward, NewCommitCount: 2, LastCommitAt: base.Add(time.Hour)},
+			want:     []models.ChangeEvent{{Kind: models.ChangeEventNewCommits, At: base.Add(time.Hour), From: "aaa", To: "bbb", Count: 2}},
+		},
+		{
+			name:     "rebased",
+			existing: &models.PullRequest{HeadSHA: "aaa", LastCommitAt: base},
+			incoming: &models.PullRequest{HeadSHA: "bbb", HeadUpdate: models.HeadUpdateRebased, LastCommitAt: pushedAt},
+			want:     []models.ChangeEvent{{Kind: models.ChangeEventRebased, At: pushedAt, From: "aaa", To: "bbb"}},
+		},
+		{
+			name:     "force-pushed",
+			existing: &models.PullRequest{HeadSHA: "aaa", LastCommitAt: base},
+			incoming: &models.PullRequest{HeadSHA: "bbb", HeadUpdate: models.HeadUpdateRewritten, LastCommitAt: pushedAt},
+			want:     []models.ChangeEvent{{Kind: models.ChangeEventForcePushed, At: pushedAt, From: "aaa", To: "bbb"}},
+		},
+		{
+			name:     "head unknown",
+			existing: &models.PullRequest{LastCommitAt: base},
+			incoming: &models.PullRequest{HeadSHA: "bbb", LastCommitAt: base.Add(time.Hour)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, events := ProcessPullRequestSyncResults(
+				[]*models.PullRequest{tt.existing},
+				[]*models.PullRequest{tt.incoming},
+			)
+			if len(events) != len(tt.want) {
+				t.Fatalf("expected %d events, got %+v", len(tt.want), events)
+			}
+			for i := range events {
+				if events[i] != tt.want[i] {
+					t.Errorf("expected %+v, got %+v", tt.want[i], events[i])
+				}
+			}
+		})
+	}
+}
+
+// TestArchiveClosedPullRequests verifies that removed pull requests with a
+// final state are archived with a merged or closed event and the rest are
+// deleted.
+func TestArchiveClosedPullRequests(t *testing.T) {
+	mergedAt := time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC)
+	closedAt := time.Date(2025, 1, 3, 12, 0, 0, 0, time.UTC)
+
+	removed := []*models.PullRequest{newPR("org/repo", 1), newPR("org/repo", 2), newPR("org/repo", 3)}
+
+	merged := newPR("org/repo", 1)
+	merged.State = models.PullRequestStateMerged
+	merged.ClosedAt = mergedAt
+	merged.MergedAt = mergedAt
+	merged.MergedBy = "carol"
+
+	closed := newPR("org/repo", 3)
+	closed.State = models.PullRequestStateClosed
+	closed.ClosedAt = closedAt
+
+	archived, deleted, events := ArchiveClosedPullRequests(removed, []*models.PullRequest{merged, closed})
+
+	if len(archived) != 2 || archived[0] != merged || archived[1] != closed {
+		t.Errorf("expected #1 and #3 to be archived with their final state, got %+v", archived)
+	}
+	if len(deleted) != 1 || deleted[0].Number != 2 {
+		t.Errorf("expected #2, still open, to be deleted, got %+v", deleted)
+	}
+
+	want := []models.ChangeEvent{
+		{Repository: "org/repo", Number: 1, Kind: models.ChangeEventMerged, Actor: "carol", At: mergedAt},
+		{Repository: "org/repo", Number: 3, Kind: models.ChangeEventClosed, At: closedAt},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %+v", len(want), events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("event %d: expected %+v, got %+v", i, want[i], events[i])
+		}
+	}
+}
+
+// TestProcessPullRequestSyncResults_CheckEvents verifies that checks that
+// start failing or recover are reported by name, and nothing else is.
+// TestProcessPullRequestSyncResults_CheckEvents verifies that checks starting
+// to fail or passing again are reported by name.
+func TestProcessPullRequestSyncResults_CheckEvents(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := base.Add(time.Hour)
+
+	check := func(name, status, conclusion string) models.Check {
+		return models.Check{Name: name, App: "GitHub Actions", Status: status, Conclusion: conclusion, CompletedAt: later}
+	}
+
+	dbPR := newPR("org/repo", 1)
+	dbPR.Checks = []models.Check{
+		check("build", "completed", "success"),
+		check("lint", "completed", "failure"),
+		check("test", "in_progress", ""),
+		check("e2e", "completed", "failure"),
+	}
+	freshPR := newPR("org/repo", 1)
+	freshPR.Checks = []models.Check{
+		check("build", "completed", "failure"),
+		check("lint", "completed", "success"),
+		check("test", "completed", "success"),
+		check("e2e", "completed", "failure"),
+		check("docs", "completed", "timed_out"),
+	}
+
+	_, updated, _, events := ProcessPullRequestSyncResults([]*models.PullRequest{dbPR}, []*models.PullRequest{freshPR})
+
+	want := []models.ChangeEvent{
+		{Repository: "org/repo", Number: 1, Kind: models.ChangeEventCheckChanged, At: later, From: "passing", To: "failing", Subject: "build"},
+		{Repository: "org/repo", Number: 1, Kind: models.ChangeEventCheckChanged, At: later, From: "failing", To: "passing", Subject: "lint"},
+		{Repository: "org/repo", Number: 1, Kind: models.ChangeEventCheckChanged, At: later, To: "failing", Subject: "docs"},
+	}
+	if len(updated) != 1 || len(events) != len(want) {
+		t.Fatalf("expected the pr to be updated with %d events, got %+v", len(want), events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("event %d: expected %+v, got %+v", i, want[i], events[i])
+		}
+	}
+	if got := events[0].String(); got != "Check build failed" {
+		t.Errorf("expected the event to name the check, got %q", got)
+	}
+}
+
+// TestProcessPullRequestSyncResults_MergeabilityEvents verifies that only
+// newly found conflicts and newly needed updates are reported.
+func TestProcessPullRequestSyncResults_MergeabilityEvents(t *testing.T) {
+	mergeable, conflicting := true, false
+
+	tests := []struct {
+		name   string
+		before func(pr *models.PullRequest)
+		after  func(pr *models.PullRequest)
+		want   string
+	}{
+		{
+			name:   "now has conflicts",
+			before: func(pr *models.PullRequest) { pr.Mergeable = &mergeable },
+			after:  func(pr *models.PullRequest) { pr.Mergeable = &conflicting },
+			want:   "Merge conflicts with main",
+		},
+		{
+			name:   "conflicts first seen",
+			before: func(pr *models.PullRequest) {},
+			after:  func(pr *models.PullRequest) { pr.Mergeable = &conflicting },
+			want:   "Merge conflicts with main",
+		},
+		{
+			name:   "still conflicting",
+			before: func(pr *models.PullRequest) { pr.Mergeable = &conflicting },
+			after:  func(pr *models.PullRequest) { pr.Mergeable = &conflicting },
+		},
+		{
+			name:   "conflicts resolved",
+			before: func(pr *models.PullRequest) { pr.Mergeable = &conflicting },
+			after:  func(pr *models.PullRequest) { pr.Mergeable = &mergeable },
+		},
+		{
+			name:   "needs update",
+			before: func(pr *models.PullRequest) { pr.MergeableState = "clean" },
+			after:  func(pr *models.PullRequest) { pr.MergeableState, pr.BehindBy = "behind", 3 },
+			want:   "Needs update, 3 commits behind main",
+		},
+		{
+			name:   "falls further behind",
+			before: func(pr *models.PullRequest) { pr.MergeableState, pr.BehindBy = "behind", 3 },
+			after:  func(pr *models.PullRequest) { pr.MergeableState, pr.BehindBy = "behind", 5 },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbPR := newPR("org/repo", 1)
+			dbPR.BaseRef = "main"
+			tt.before(dbPR)
+			freshPR := newPR("org/repo", 1)
+			freshPR.BaseRef = "main"
+			tt.after(freshPR)
+
+			_, _, _, events := ProcessPullRequestSyncResults([]*models.PullRequest{dbPR}, []*models.PullRequest{freshPR})
+
+			var got []string
+			for _, event := range events {
+				got = append(got, event.String())
+			}
+			var want []string
+			if tt.want != "" {
+				want = []string{tt.want}
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("expected events %q, got %q", want, got)
+			}
+		})
+	}
+}
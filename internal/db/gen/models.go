@@ -8,6 +8,43 @@ import (
 	"database/sql"
 )
 
+type GithubHost struct {
+	Host        string `json:"host"`
+	ApiUrl      string `json:"api_url"`
+	WebUrl      string `json:"web_url"`
+	AccessToken string `json:"access_token"`
+	ProxyUrl    string `json:"proxy_url"`
+	CaFile      string `json:"ca_file"`
+	Backend     string `json:"backend"`
+}
+
+type HttpCache struct {
+	Url          string `json:"url"`
+	Etag         string `json:"etag"`
+	LastModified string `json:"last_modified"`
+	Link         string `json:"link"`
+	Body         []byte `json:"body"`
+	StoredAtUnix int64  `json:"stored_at_unix"`
+}
+
+type IgnoredCommenter struct {
+	Login string `json:"login"`
+}
+
+type PrEvent struct {
+	ID             int64  `json:"id"`
+	Repository     string `json:"repository"`
+	Number         int64  `json:"number"`
+	Kind           string `json:"kind"`
+	Actor          string `json:"actor"`
+	OccurredAtUnix int64  `json:"occurred_at_unix"`
+	FromValue      string `json:"from_value"`
+	ToValue        string `json:"to_value"`
+	RecordedAtUnix int64  `json:"recorded_at_unix"`
+	Count          int64  `json:"count"`
+	Subject        string `json:"subject"`
+}
+
 type PullRequest struct {
 	Number                 int64         `json:"number"`
 	Title                  string        `json:"title"`
@@ -22,6 +59,57 @@ type PullRequest struct {
 	LastCiStatusUpdateUnix int64         `json:"last_ci_status_update_unix"`
 	LastAcknowledgedUnix   sql.NullInt64 `json:"last_acknowledged_unix"`
 	RequestedReviewers     string        `json:"requested_reviewers"`
+	LastSyncedUnix         int64         `json:"last_synced_unix"`
+	SyncError              string        `json:"sync_error"`
+	HtmlUrl                string        `json:"html_url"`
+	HeadSha                string        `json:"head_sha"`
+	LastFetchedUnix        int64         `json:"last_fetched_unix"`
+	Reviews                string        `json:"reviews"`
+	ReviewDecision         string        `json:"review_decision"`
+	HeadCommitAuthor       string        `json:"head_commit_author"`
+	BaseSha                string        `json:"base_sha"`
+	CommitCount            int64         `json:"commit_count"`
+	LastCommentAuthor      string        `json:"last_comment_author"`
+	State                  string        `json:"state"`
+	ClosedAtUnix           int64         `json:"closed_at_unix"`
+	MergedAtUnix           int64         `json:"merged_at_unix"`
+	MergedBy               string        `json:"merged_by"`
+	Checks                 string        `json:"checks"`
+	BaseRef                string        `json:"base_ref"`
+	Mergeable              sql.NullBool  `json:"mergeable"`
+	MergeableState         string        `json:"mergeable_state"`
+	BehindBy               int64         `json:"behind_by"`
+}
+
+type SyncRun struct {
+	ID                 int64  `json:"id"`
+	StartedAtUnix      int64  `json:"started_at_unix"`
+	FinishedAtUnix     int64  `json:"finished_at_unix"`
+	Outcome            string `json:"outcome"`
+	NewCount           int64  `json:"new_count"`
+	UpdatedCount       int64  `json:"updated_count"`
+	RemovedCount       int64  `json:"removed_count"`
+	StaleCount         int64  `json:"stale_count"`
+	ApiCalls           int64  `json:"api_calls"`
+	Error              string `json:"error"`
+	CachedResponses    int64  `json:"cached_responses"`
+	RateLimitLimit     int64  `json:"rate_limit_limit"`
+	RateLimitRemaining int64  `json:"rate_limit_remaining"`
+	RateLimitResetUnix int64  `json:"rate_limit_reset_unix"`
+	Retries            int64  `json:"retries"`
+}
+
+type SyncRunRepository struct {
+	ID           int64  `json:"id"`
+	SyncRunID    int64  `json:"sync_run_id"`
+	Repository   string `json:"repository"`
+	Outcome      string `json:"outcome"`
+	NewCount     int64  `json:"new_count"`
+	UpdatedCount int64  `json:"updated_count"`
+	RemovedCount int64  `json:"removed_count"`
+	StaleCount   int64  `json:"stale_count"`
+	DurationMs   int64  `json:"duration_ms"`
+	Error        string `json:"error"`
 }
 
 type TrackedAuthor struct {
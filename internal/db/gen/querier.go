@@ -9,17 +9,36 @@ import (
 )
 
 type Querier interface {
+	CreatePrEvent(ctx context.Context, arg CreatePrEventParams) error
+	CreateSyncRun(ctx context.Context, arg CreateSyncRunParams) (int64, error)
+	CreateSyncRunRepository(ctx context.Context, arg CreateSyncRunRepositoryParams) error
+	DeleteCachedResponsesStoredBefore(ctx context.Context, storedAtUnix int64) error
+	DeleteGitHubHost(ctx context.Context, host string) error
+	DeleteIgnoredCommenter(ctx context.Context, login string) error
 	DeletePrByRepositoryAndNumber(ctx context.Context, arg DeletePrByRepositoryAndNumberParams) error
+	DeletePrEvents(ctx context.Context, arg DeletePrEventsParams) error
 	DeleteTrackedRepository(ctx context.Context, repository string) error
 	GetAllPullRequests(ctx context.Context) ([]PullRequest, error)
+	GetCachedResponse(ctx context.Context, url string) (GetCachedResponseRow, error)
+	GetGitHubHosts(ctx context.Context) ([]GithubHost, error)
+	GetIgnoredCommenters(ctx context.Context) ([]string, error)
+	GetPrEvents(ctx context.Context, arg GetPrEventsParams) ([]PrEvent, error)
 	GetPrsByRepository(ctx context.Context, repository string) ([]PullRequest, error)
 	GetPullRequestByRepoAndNumber(ctx context.Context, arg GetPullRequestByRepoAndNumberParams) (PullRequest, error)
+	GetPullRequestsByState(ctx context.Context, arg GetPullRequestsByStateParams) ([]PullRequest, error)
+	GetRecentSyncRuns(ctx context.Context, limit int64) ([]SyncRun, error)
+	GetSyncRunRepositories(ctx context.Context, syncRunID int64) ([]SyncRunRepository, error)
 	GetTrackedAuthors(ctx context.Context) ([]string, error)
 	GetTrackedRepositories(ctx context.Context) ([]string, error)
 	GetUsers(ctx context.Context) ([]User, error)
+	MarkPullRequestSyncFailed(ctx context.Context, arg MarkPullRequestSyncFailedParams) error
+	MarkPullRequestSynced(ctx context.Context, arg MarkPullRequestSyncedParams) error
+	SaveIgnoredCommenter(ctx context.Context, login string) error
 	SaveTrackedAuthor(ctx context.Context, author string) error
 	SaveTrackedRepository(ctx context.Context, repository string) error
 	SaveUser(ctx context.Context, arg SaveUserParams) error
+	UpsertCachedResponse(ctx context.Context, arg UpsertCachedResponseParams) error
+	UpsertGitHubHost(ctx context.Context, arg UpsertGitHubHostParams) error
 	UpsertPullRequest(ctx context.Context, arg UpsertPullRequestParams) error
 }
 
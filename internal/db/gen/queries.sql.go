@@ -10,6 +10,186 @@ import (
 	"database/sql"
 )
 
+const createPrEvent = `-- name: CreatePrEvent :exec
+INSERT INTO pr_events (
+  repository,
+  number,
+  kind,
+  actor,
+  occurred_at_unix,
+  from_value,
+  to_value,
+  recorded_at_unix,
+  count,
+  subject
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+`
+
+type CreatePrEventParams struct {
+	Repository     string `json:"repository"`
+	Number         int64  `json:"number"`
+	Kind           string `json:"kind"`
+	Actor          string `json:"actor"`
+	OccurredAtUnix int64  `json:"occurred_at_unix"`
+	FromValue      string `json:"from_value"`
+	ToValue        string `json:"to_value"`
+	RecordedAtUnix int64  `json:"recorded_at_unix"`
+	Count          int64  `json:"count"`
+	Subject        string `json:"subject"`
+}
+
+func (q *Queries) CreatePrEvent(ctx context.Context, arg CreatePrEventParams) error {
+	_, err := q.db.ExecContext(ctx, createPrEvent,
+		arg.Repository,
+		arg.Number,
+		arg.Kind,
+		arg.Actor,
+		arg.OccurredAtUnix,
+		arg.FromValue,
+		arg.ToValue,
+		arg.RecordedAtUnix,
+		arg.Count,
+		arg.Subject,
+	)
+	return err
+}
+
+const createSyncRun = `-- name: CreateSyncRun :one
+INSERT INTO sync_runs (
+  started_at_unix,
+  finished_at_unix,
+  outcome,
+  new_count,
+  updated_count,
+  removed_count,
+  stale_count,
+  api_calls,
+  error,
+  cached_responses,
+  rate_limit_limit,
+  rate_limit_remaining,
+  rate_limit_reset_unix,
+  retries
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+RETURNING id
+`
+
+type CreateSyncRunParams struct {
+	StartedAtUnix      int64  `json:"started_at_unix"`
+	FinishedAtUnix     int64  `json:"finished_at_unix"`
+	Outcome            string `json:"outcome"`
+	NewCount           int64  `json:"new_count"`
+	UpdatedCount       int64  `json:"updated_count"`
+	RemovedCount       int64  `json:"removed_count"`
+	StaleCount         int64  `json:"stale_count"`
+	ApiCalls           int64  `json:"api_calls"`
+	Error              string `json:"error"`
+	CachedResponses    int64  `json:"cached_responses"`
+	RateLimitLimit     int64  `json:"rate_limit_limit"`
+	RateLimitRemaining int64  `json:"rate_limit_remaining"`
+	RateLimitResetUnix int64  `json:"rate_limit_reset_unix"`
+	Retries            int64  `json:"retries"`
+}
+
+func (q *Queries) CreateSyncRun(ctx context.Context, arg CreateSyncRunParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, createSyncRun,
+		arg.StartedAtUnix,
+		arg.FinishedAtUnix,
+		arg.Outcome,
+		arg.NewCount,
+		arg.UpdatedCount,
+		arg.RemovedCount,
+		arg.StaleCount,
+		arg.ApiCalls,
+		arg.Error,
+		arg.CachedResponses,
+		arg.RateLimitLimit,
+		arg.RateLimitRemaining,
+		arg.RateLimitResetUnix,
+		arg.Retries,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createSyncRunRepository = `-- name: CreateSyncRunRepository :exec
+INSERT INTO sync_run_repositories (
+  sync_run_id,
+  repository,
+  outcome,
+  new_count,
+  updated_count,
+  removed_count,
+  stale_count,
+  duration_ms,
+  error
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+`
+
+type CreateSyncRunRepositoryParams struct {
+	SyncRunID    int64  `json:"sync_run_id"`
+	Repository   string `json:"repository"`
+	Outcome      string `json:"outcome"`
+	NewCount     int64  `json:"new_count"`
+	UpdatedCount int64  `json:"updated_count"`
+	RemovedCount int64  `json:"removed_count"`
+	StaleCount   int64  `json:"stale_count"`
+	DurationMs   int64  `json:"duration_ms"`
+	Error        string `json:"error"`
+}
+
+func (q *Queries) CreateSyncRunRepository(ctx context.Context, arg CreateSyncRunRepositoryParams) error {
+	_, err := q.db.ExecContext(ctx, createSyncRunRepository,
+		arg.SyncRunID,
+		arg.Repository,
+		arg.Outcome,
+		arg.NewCount,
+		arg.UpdatedCount,
+		arg.RemovedCount,
+		arg.StaleCount,
+		arg.DurationMs,
+		arg.Error,
+	)
+	return err
+}
+
+const deleteCachedResponsesStoredBefore = `-- name: DeleteCachedResponsesStoredBefore :exec
+DELETE FROM http_cache
+WHERE stored_at_unix < ?
+`
+
+func (q *Queries) DeleteCachedResponsesStoredBefore(ctx context.Context, storedAtUnix int64) error {
+	_, err := q.db.ExecContext(ctx, deleteCachedResponsesStoredBefore, storedAtUnix)
+	return err
+}
+
+const deleteGitHubHost = `-- name: DeleteGitHubHost :exec
+DELETE FROM github_hosts
+WHERE host = ?
+`
+
+func (q *Queries) DeleteGitHubHost(ctx context.Context, host string) error {
+	_, err := q.db.ExecContext(ctx, deleteGitHubHost, host)
+	return err
+}
+
+const deleteIgnoredCommenter = `-- name: DeleteIgnoredCommenter :exec
+DELETE FROM ignored_commenters
+WHERE login = ?
+`
+
+func (q *Queries) DeleteIgnoredCommenter(ctx context.Context, login string) error {
+	_, err := q.db.ExecContext(ctx, deleteIgnoredCommenter, login)
+	return err
+}
+
 const deletePrByRepositoryAndNumber = `-- name: DeletePrByRepositoryAndNumber :exec
 DELETE FROM pull_requests
 WHERE repository = ?
@@ -26,6 +206,22 @@ func (q *Queries) DeletePrByRepositoryAndNumber(ctx context.Context, arg DeleteP
 	return err
 }
 
+const deletePrEvents = `-- name: DeletePrEvents :exec
+DELETE FROM pr_events
+WHERE repository = ?
+AND number = ?
+`
+
+type DeletePrEventsParams struct {
+	Repository string `json:"repository"`
+	Number     int64  `json:"number"`
+}
+
+func (q *Queries) DeletePrEvents(ctx context.Context, arg DeletePrEventsParams) error {
+	_, err := q.db.ExecContext(ctx, deletePrEvents, arg.Repository, arg.Number)
+	return err
+}
+
 const deleteTrackedRepository = `-- name: DeleteTrackedRepository :exec
 DELETE FROM tracked_repositories
 WHERE repository = ?
@@ -50,8 +246,29 @@ SELECT
   last_commit_unix,
   last_ci_status_update_unix,
   last_acknowledged_unix,
-  requested_reviewers
+  requested_reviewers,
+  last_synced_unix,
+  sync_error,
+  html_url,
+  head_sha,
+  last_fetched_unix,
+  reviews,
+  review_decision,
+  head_commit_author,
+  base_sha,
+  commit_count,
+  last_comment_author,
+  state,
+  closed_at_unix,
+  merged_at_unix,
+  merged_by,
+  checks,
+  base_ref,
+  mergeable,
+  mergeable_state,
+  behind_by
 FROM pull_requests
+WHERE state = 'open'
 `
 
 func (q *Queries) GetAllPullRequests(ctx context.Context) ([]PullRequest, error) {
@@ -77,6 +294,176 @@ func (q *Queries) GetAllPullRequests(ctx context.Context) ([]PullRequest, error)
 			&i.LastCiStatusUpdateUnix,
 			&i.LastAcknowledgedUnix,
 			&i.RequestedReviewers,
+			&i.LastSyncedUnix,
+			&i.SyncError,
+			&i.HtmlUrl,
+			&i.HeadSha,
+			&i.LastFetchedUnix,
+			&i.Reviews,
+			&i.ReviewDecision,
+			&i.HeadCommitAuthor,
+			&i.BaseSha,
+			&i.CommitCount,
+			&i.LastCommentAuthor,
+			&i.State,
+			&i.ClosedAtUnix,
+			&i.MergedAtUnix,
+			&i.MergedBy,
+			&i.Checks,
+			&i.BaseRef,
+			&i.Mergeable,
+			&i.MergeableState,
+			&i.BehindBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCachedResponse = `-- name: GetCachedResponse :one
+SELECT etag, last_modified, link, body
+FROM http_cache
+WHERE url = ?
+LIMIT 1
+`
+
+type GetCachedResponseRow struct {
+	Etag         string `json:"etag"`
+	LastModified string `json:"last_modified"`
+	Link         string `json:"link"`
+	Body         []byte `json:"body"`
+}
+
+func (q *Queries) GetCachedResponse(ctx context.Context, url string) (GetCachedResponseRow, error) {
+	row := q.db.QueryRowContext(ctx, getCachedResponse, url)
+	var i GetCachedResponseRow
+	err := row.Scan(
+		&i.Etag,
+		&i.LastModified,
+		&i.Link,
+		&i.Body,
+	)
+	return i, err
+}
+
+const getGitHubHosts = `-- name: GetGitHubHosts :many
+SELECT host, api_url, web_url, access_token, proxy_url, ca_file, backend
+FROM github_hosts
+ORDER BY host
+`
+
+func (q *Queries) GetGitHubHosts(ctx context.Context) ([]GithubHost, error) {
+	rows, err := q.db.QueryContext(ctx, getGitHubHosts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GithubHost
+	for rows.Next() {
+		var i GithubHost
+		if err := rows.Scan(
+			&i.Host,
+			&i.ApiUrl,
+			&i.WebUrl,
+			&i.AccessToken,
+			&i.ProxyUrl,
+			&i.CaFile,
+			&i.Backend,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getIgnoredCommenters = `-- name: GetIgnoredCommenters :many
+SELECT login FROM ignored_commenters
+ORDER BY login
+`
+
+func (q *Queries) GetIgnoredCommenters(ctx context.Context) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, getIgnoredCommenters)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var login string
+		if err := rows.Scan(&login); err != nil {
+			return nil, err
+		}
+		items = append(items, login)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPrEvents = `-- name: GetPrEvents :many
+SELECT
+  id,
+  repository,
+  number,
+  kind,
+  actor,
+  occurred_at_unix,
+  from_value,
+  to_value,
+  recorded_at_unix,
+  count,
+  subject
+FROM pr_events
+WHERE repository = ?
+AND number = ?
+ORDER BY occurred_at_unix, id
+`
+
+type GetPrEventsParams struct {
+	Repository string `json:"repository"`
+	Number     int64  `json:"number"`
+}
+
+func (q *Queries) GetPrEvents(ctx context.Context, arg GetPrEventsParams) ([]PrEvent, error) {
+	rows, err := q.db.QueryContext(ctx, getPrEvents, arg.Repository, arg.Number)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PrEvent
+	for rows.Next() {
+		var i PrEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.Repository,
+			&i.Number,
+			&i.Kind,
+			&i.Actor,
+			&i.OccurredAtUnix,
+			&i.FromValue,
+			&i.ToValue,
+			&i.RecordedAtUnix,
+			&i.Count,
+			&i.Subject,
 		); err != nil {
 			return nil, err
 		}
@@ -105,9 +492,30 @@ SELECT
   last_commit_unix,
   last_ci_status_update_unix,
   last_acknowledged_unix,
-  requested_reviewers
+  requested_reviewers,
+  last_synced_unix,
+  sync_error,
+  html_url,
+  head_sha,
+  last_fetched_unix,
+  reviews,
+  review_decision,
+  head_commit_author,
+  base_sha,
+  commit_count,
+  last_comment_author,
+  state,
+  closed_at_unix,
+  merged_at_unix,
+  merged_by,
+  checks,
+  base_ref,
+  mergeable,
+  mergeable_state,
+  behind_by
 FROM pull_requests
 WHERE repository = ?
+AND state = 'open'
 `
 
 func (q *Queries) GetPrsByRepository(ctx context.Context, repository string) ([]PullRequest, error) {
@@ -133,6 +541,26 @@ func (q *Queries) GetPrsByRepository(ctx context.Context, repository string) ([]
 			&i.LastCiStatusUpdateUnix,
 			&i.LastAcknowledgedUnix,
 			&i.RequestedReviewers,
+			&i.LastSyncedUnix,
+			&i.SyncError,
+			&i.HtmlUrl,
+			&i.HeadSha,
+			&i.LastFetchedUnix,
+			&i.Reviews,
+			&i.ReviewDecision,
+			&i.HeadCommitAuthor,
+			&i.BaseSha,
+			&i.CommitCount,
+			&i.LastCommentAuthor,
+			&i.State,
+			&i.ClosedAtUnix,
+			&i.MergedAtUnix,
+			&i.MergedBy,
+			&i.Checks,
+			&i.BaseRef,
+			&i.Mergeable,
+			&i.MergeableState,
+			&i.BehindBy,
 		); err != nil {
 			return nil, err
 		}
@@ -161,7 +589,27 @@ SELECT
   last_commit_unix,
   last_ci_status_update_unix,
   last_acknowledged_unix,
-  requested_reviewers
+  requested_reviewers,
+  last_synced_unix,
+  sync_error,
+  html_url,
+  head_sha,
+  last_fetched_unix,
+  reviews,
+  review_decision,
+  head_commit_author,
+  base_sha,
+  commit_count,
+  last_comment_author,
+  state,
+  closed_at_unix,
+  merged_at_unix,
+  merged_by,
+  checks,
+  base_ref,
+  mergeable,
+  mergeable_state,
+  behind_by
 FROM pull_requests
 WHERE repository = ?
 AND number = ?
@@ -190,10 +638,245 @@ func (q *Queries) GetPullRequestByRepoAndNumber(ctx context.Context, arg GetPull
 		&i.LastCiStatusUpdateUnix,
 		&i.LastAcknowledgedUnix,
 		&i.RequestedReviewers,
+		&i.LastSyncedUnix,
+		&i.SyncError,
+		&i.HtmlUrl,
+		&i.HeadSha,
+		&i.LastFetchedUnix,
+		&i.Reviews,
+		&i.ReviewDecision,
+		&i.HeadCommitAuthor,
+		&i.BaseSha,
+		&i.CommitCount,
+		&i.LastCommentAuthor,
+		&i.State,
+		&i.ClosedAtUnix,
+		&i.MergedAtUnix,
+		&i.MergedBy,
+		&i.Checks,
+		&i.BaseRef,
+		&i.Mergeable,
+		&i.MergeableState,
+		&i.BehindBy,
 	)
 	return i, err
 }
 
+const getPullRequestsByState = `-- name: GetPullRequestsByState :many
+SELECT
+  number,
+  title,
+  repository,
+  author,
+  draft,
+  created_at_unix,
+  updated_at_unix,
+  ci_status,
+  last_comment_unix,
+  last_commit_unix,
+  last_ci_status_update_unix,
+  last_acknowledged_unix,
+  requested_reviewers,
+  last_synced_unix,
+  sync_error,
+  html_url,
+  head_sha,
+  last_fetched_unix,
+  reviews,
+  review_decision,
+  head_commit_author,
+  base_sha,
+  commit_count,
+  last_comment_author,
+  state,
+  closed_at_unix,
+  merged_at_unix,
+  merged_by,
+  checks,
+  base_ref,
+  mergeable,
+  mergeable_state,
+  behind_by
+FROM pull_requests
+WHERE state = ?
+ORDER BY closed_at_unix DESC
+LIMIT ?
+`
+
+type GetPullRequestsByStateParams struct {
+	State string `json:"state"`
+	Limit int64  `json:"limit"`
+}
+
+func (q *Queries) GetPullRequestsByState(ctx context.Context, arg GetPullRequestsByStateParams) ([]PullRequest, error) {
+	rows, err := q.db.QueryContext(ctx, getPullRequestsByState, arg.State, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PullRequest
+	for rows.Next() {
+		var i PullRequest
+		if err := rows.Scan(
+			&i.Number,
+			&i.Title,
+			&i.Repository,
+			&i.Author,
+			&i.Draft,
+			&i.CreatedAtUnix,
+			&i.UpdatedAtUnix,
+			&i.CiStatus,
+			&i.LastCommentUnix,
+			&i.LastCommitUnix,
+			&i.LastCiStatusUpdateUnix,
+			&i.LastAcknowledgedUnix,
+			&i.RequestedReviewers,
+			&i.LastSyncedUnix,
+			&i.SyncError,
+			&i.HtmlUrl,
+			&i.HeadSha,
+			&i.LastFetchedUnix,
+			&i.Reviews,
+			&i.ReviewDecision,
+			&i.HeadCommitAuthor,
+			&i.BaseSha,
+			&i.CommitCount,
+			&i.LastCommentAuthor,
+			&i.State,
+			&i.ClosedAtUnix,
+			&i.MergedAtUnix,
+			&i.MergedBy,
+			&i.Checks,
+			&i.BaseRef,
+			&i.Mergeable,
+			&i.MergeableState,
+			&i.BehindBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRecentSyncRuns = `-- name: GetRecentSyncRuns :many
+SELECT
+  id,
+  started_at_unix,
+  finished_at_unix,
+  outcome,
+  new_count,
+  updated_count,
+  removed_count,
+  stale_count,
+  api_calls,
+  error,
+  cached_responses,
+  rate_limit_limit,
+  rate_limit_remaining,
+  rate_limit_reset_unix,
+  retries
+FROM sync_runs
+ORDER BY started_at_unix DESC, id DESC
+LIMIT ?
+`
+
+func (q *Queries) GetRecentSyncRuns(ctx context.Context, limit int64) ([]SyncRun, error) {
+	rows, err := q.db.QueryContext(ctx, getRecentSyncRuns, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SyncRun
+	for rows.Next() {
+		var i SyncRun
+		if err := rows.Scan(
+			&i.ID,
+			&i.StartedAtUnix,
+			&i.FinishedAtUnix,
+			&i.Outcome,
+			&i.NewCount,
+			&i.UpdatedCount,
+			&i.RemovedCount,
+			&i.StaleCount,
+			&i.ApiCalls,
+			&i.Error,
+			&i.CachedResponses,
+			&i.RateLimitLimit,
+			&i.RateLimitRemaining,
+			&i.RateLimitResetUnix,
+			&i.Retries,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSyncRunRepositories = `-- name: GetSyncRunRepositories :many
+SELECT
+  id,
+  sync_run_id,
+  repository,
+  outcome,
+  new_count,
+  updated_count,
+  removed_count,
+  stale_count,
+  duration_ms,
+  error
+FROM sync_run_repositories
+WHERE sync_run_id = ?
+ORDER BY id
+`
+
+func (q *Queries) GetSyncRunRepositories(ctx context.Context, syncRunID int64) ([]SyncRunRepository, error) {
+	rows, err := q.db.QueryContext(ctx, getSyncRunRepositories, syncRunID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SyncRunRepository
+	for rows.Next() {
+		var i SyncRunRepository
+		if err := rows.Scan(
+			&i.ID,
+			&i.SyncRunID,
+			&i.Repository,
+			&i.Outcome,
+			&i.NewCount,
+			&i.UpdatedCount,
+			&i.RemovedCount,
+			&i.StaleCount,
+			&i.DurationMs,
+			&i.Error,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getTrackedAuthors = `-- name: GetTrackedAuthors :many
 SELECT author FROM tracked_authors
 `
@@ -275,6 +958,77 @@ func (q *Queries) GetUsers(ctx context.Context) ([]User, error) {
 	return items, nil
 }
 
+const markPullRequestSyncFailed = `-- name: MarkPullRequestSyncFailed :exec
+UPDATE pull_requests
+SET sync_error = ?
+WHERE repository = ?
+AND number = ?
+`
+
+type MarkPullRequestSyncFailedParams struct {
+	SyncError  string `json:"sync_error"`
+	Repository string `json:"repository"`
+	Number     int64  `json:"number"`
+}
+
+func (q *Queries) MarkPullRequestSyncFailed(ctx context.Context, arg MarkPullRequestSyncFailedParams) error {
+	_, err := q.db.ExecContext(ctx, markPullRequestSyncFailed, arg.SyncError, arg.Repository, arg.Number)
+	return err
+}
+
+const markPullRequestSynced = `-- name: MarkPullRequestSynced :exec
+UPDATE pull_requests
+SET last_synced_unix = ?,
+  sync_error = '',
+  updated_at_unix = ?,
+  head_sha = ?,
+  last_fetched_unix = ?,
+  checks = ?,
+  mergeable = ?,
+  mergeable_state = ?,
+  behind_by = ?
+WHERE repository = ?
+AND number = ?
+`
+
+type MarkPullRequestSyncedParams struct {
+	LastSyncedUnix  int64        `json:"last_synced_unix"`
+	UpdatedAtUnix   int64        `json:"updated_at_unix"`
+	HeadSha         string       `json:"head_sha"`
+	LastFetchedUnix int64        `json:"last_fetched_unix"`
+	Checks          string       `json:"checks"`
+	Mergeable       sql.NullBool `json:"mergeable"`
+	MergeableState  string       `json:"mergeable_state"`
+	BehindBy        int64        `json:"behind_by"`
+	Repository      string       `json:"repository"`
+	Number          int64        `json:"number"`
+}
+
+func (q *Queries) MarkPullRequestSynced(ctx context.Context, arg MarkPullRequestSyncedParams) error {
+	_, err := q.db.ExecContext(ctx, markPullRequestSynced,
+		arg.LastSyncedUnix,
+		arg.UpdatedAtUnix,
+		arg.HeadSha,
+		arg.LastFetchedUnix,
+		arg.Checks,
+		arg.Mergeable,
+		arg.MergeableState,
+		arg.BehindBy,
+		arg.Repository,
+		arg.Number,
+	)
+	return err
+}
+
+const saveIgnoredCommenter = `-- name: SaveIgnoredCommenter :exec
+INSERT OR IGNORE INTO ignored_commenters (login) VALUES (?)
+`
+
+func (q *Queries) SaveIgnoredCommenter(ctx context.Context, login string) error {
+	_, err := q.db.ExecContext(ctx, saveIgnoredCommenter, login)
+	return err
+}
+
 const saveTrackedAuthor = `-- name: SaveTrackedAuthor :exec
 INSERT INTO tracked_authors (author) VALUES (?)
 `
@@ -307,6 +1061,90 @@ func (q *Queries) SaveUser(ctx context.Context, arg SaveUserParams) error {
 	return err
 }
 
+const upsertCachedResponse = `-- name: UpsertCachedResponse :exec
+INSERT INTO http_cache (
+  url,
+  etag,
+  last_modified,
+  link,
+  body,
+  stored_at_unix
+) VALUES (
+  ?, ?, ?, ?, ?, ?
+)
+ON CONFLICT(url) DO UPDATE SET
+  etag = excluded.etag,
+  last_modified = excluded.last_modified,
+  link = excluded.link,
+  body = excluded.body,
+  stored_at_unix = excluded.stored_at_unix
+`
+
+type UpsertCachedResponseParams struct {
+	Url          string `json:"url"`
+	Etag         string `json:"etag"`
+	LastModified string `json:"last_modified"`
+	Link         string `json:"link"`
+	Body         []byte `json:"body"`
+	StoredAtUnix int64  `json:"stored_at_unix"`
+}
+
+func (q *Queries) UpsertCachedResponse(ctx context.Context, arg UpsertCachedResponseParams) error {
+	_, err := q.db.ExecContext(ctx, upsertCachedResponse,
+		arg.Url,
+		arg.Etag,
+		arg.LastModified,
+		arg.Link,
+		arg.Body,
+		arg.StoredAtUnix,
+	)
+	return err
+}
+
+const upsertGitHubHost = `-- name: UpsertGitHubHost :exec
+INSERT INTO github_hosts (
+  host,
+  api_url,
+  web_url,
+  access_token,
+  proxy_url,
+  ca_file,
+  backend
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?
+)
+ON CONFLICT(host) DO UPDATE SET
+  api_url = excluded.api_url,
+  web_url = excluded.web_url,
+  access_token = excluded.access_token,
+  proxy_url = excluded.proxy_url,
+  ca_file = excluded.ca_file,
+  backend = excluded.backend
+`
+
+type UpsertGitHubHostParams struct {
+	Host        string `json:"host"`
+	ApiUrl      string `json:"api_url"`
+	WebUrl      string `json:"web_url"`
+	AccessToken string `json:"access_token"`
+	ProxyUrl    string `json:"proxy_url"`
+	CaFile      string `json:"ca_file"`
+	Backend     string `json:"backend"`
+}
+
+func (q *Queries) UpsertGitHubHost(ctx context.Context, arg UpsertGitHubHostParams) error {
+	_, err := q.db.ExecContext(ctx, upsertGitHubHost,
+		arg.Host,
+		arg.ApiUrl,
+		arg.WebUrl,
+		arg.AccessToken,
+		arg.ProxyUrl,
+		arg.CaFile,
+		arg.Backend,
+	)
+	return err
+}
+
 const upsertPullRequest = `-- name: UpsertPullRequest :exec
 INSERT INTO pull_requests (
   number,
@@ -321,9 +1159,29 @@ INSERT INTO pull_requests (
   last_commit_unix,
   last_ci_status_update_unix,
   last_acknowledged_unix,
-  requested_reviewers
+  requested_reviewers,
+  last_synced_unix,
+  sync_error,
+  html_url,
+  head_sha,
+  last_fetched_unix,
+  reviews,
+  review_decision,
+  head_commit_author,
+  base_sha,
+  commit_count,
+  last_comment_author,
+  state,
+  closed_at_unix,
+  merged_at_unix,
+  merged_by,
+  checks,
+  base_ref,
+  mergeable,
+  mergeable_state,
+  behind_by
 ) VALUES (
-  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
 )
 ON CONFLICT(repository, number) DO UPDATE SET
   title = excluded.title,
@@ -336,7 +1194,27 @@ ON CONFLICT(repository, number) DO UPDATE SET
   last_commit_unix = excluded.last_commit_unix,
   last_ci_status_update_unix = excluded.last_ci_status_update_unix,
   last_acknowledged_unix = excluded.last_acknowledged_unix,
-  requested_reviewers = excluded.requested_reviewers
+  requested_reviewers = excluded.requested_reviewers,
+  last_synced_unix = excluded.last_synced_unix,
+  sync_error = excluded.sync_error,
+  html_url = excluded.html_url,
+  head_sha = excluded.head_sha,
+  last_fetched_unix = excluded.last_fetched_unix,
+  reviews = excluded.reviews,
+  review_decision = excluded.review_decision,
+  head_commit_author = excluded.head_commit_author,
+  base_sha = excluded.base_sha,
+  commit_count = excluded.commit_count,
+  last_comment_author = excluded.last_comment_author,
+  state = excluded.state,
+  closed_at_unix = excluded.closed_at_unix,
+  merged_at_unix = excluded.merged_at_unix,
+  merged_by = excluded.merged_by,
+  checks = excluded.checks,
+  base_ref = excluded.base_ref,
+  mergeable = excluded.mergeable,
+  mergeable_state = excluded.mergeable_state,
+  behind_by = excluded.behind_by
 `
 
 type UpsertPullRequestParams struct {
@@ -353,6 +1231,26 @@ type UpsertPullRequestParams struct {
 	LastCiStatusUpdateUnix int64         `json:"last_ci_status_update_unix"`
 	LastAcknowledgedUnix   sql.NullInt64 `json:"last_acknowledged_unix"`
 	RequestedReviewers     string        `json:"requested_reviewers"`
+	LastSyncedUnix         int64         `json:"last_synced_unix"`
+	SyncError              string        `json:"sync_error"`
+	HtmlUrl                string        `json:"html_url"`
+	HeadSha                string        `json:"head_sha"`
+	LastFetchedUnix        int64         `json:"last_fetched_unix"`
+	Reviews                string        `json:"reviews"`
+	ReviewDecision         string        `json:"review_decision"`
+	HeadCommitAuthor       string        `json:"head_commit_author"`
+	BaseSha                string        `json:"base_sha"`
+	CommitCount            int64         `json:"commit_count"`
+	LastCommentAuthor      string        `json:"last_comment_author"`
+	State                  string        `json:"state"`
+	ClosedAtUnix           int64         `json:"closed_at_unix"`
+	MergedAtUnix           int64         `json:"merged_at_unix"`
+	MergedBy               string        `json:"merged_by"`
+	Checks                 string        `json:"checks"`
+	BaseRef                string        `json:"base_ref"`
+	Mergeable              sql.NullBool  `json:"mergeable"`
+	MergeableState         string        `json:"mergeable_state"`
+	BehindBy               int64         `json:"behind_by"`
 }
 
 func (q *Queries) UpsertPullRequest(ctx context.Context, arg UpsertPullRequestParams) error {
@@ -370,6 +1268,26 @@ func (q *Queries) UpsertPullRequest(ctx context.Context, arg UpsertPullRequestPa
 		arg.LastCiStatusUpdateUnix,
 		arg.LastAcknowledgedUnix,
 		arg.RequestedReviewers,
+		arg.LastSyncedUnix,
+		arg.SyncError,
+		arg.HtmlUrl,
+		arg.HeadSha,
+		arg.LastFetchedUnix,
+		arg.Reviews,
+		arg.ReviewDecision,
+		arg.HeadCommitAuthor,
+		arg.BaseSha,
+		arg.CommitCount,
+		arg.LastCommentAuthor,
+		arg.State,
+		arg.ClosedAtUnix,
+		arg.MergedAtUnix,
+		arg.MergedBy,
+		arg.Checks,
+		arg.BaseRef,
+		arg.Mergeable,
+		arg.MergeableState,
+		arg.BehindBy,
 	)
 	return err
 }
@@ -0,0 +1,2 @@
+ALTER TABLE pull_requests ADD COLUMN last_synced_unix INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE pull_requests ADD COLUMN sync_error TEXT NOT NULL DEFAULT '';
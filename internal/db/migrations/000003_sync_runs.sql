@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS sync_runs (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  started_at_unix INTEGER NOT NULL,
+  finished_at_unix INTEGER NOT NULL,
+  outcome TEXT NOT NULL,
+  new_count INTEGER NOT NULL,
+  updated_count INTEGER NOT NULL,
+  removed_count INTEGER NOT NULL,
+  stale_count INTEGER NOT NULL,
+  api_calls INTEGER NOT NULL,
+  error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS sync_run_repositories (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  sync_run_id INTEGER NOT NULL REFERENCES sync_runs (id) ON DELETE CASCADE,
+  repository TEXT NOT NULL,
+  outcome TEXT NOT NULL,
+  new_count INTEGER NOT NULL,
+  updated_count INTEGER NOT NULL,
+  removed_count INTEGER NOT NULL,
+  stale_count INTEGER NOT NULL,
+  duration_ms INTEGER NOT NULL,
+  error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS sync_run_repositories_sync_run_id ON sync_run_repositories (sync_run_id);
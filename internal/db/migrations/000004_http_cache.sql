@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS http_cache (
+  url TEXT NOT NULL PRIMARY KEY,
+  etag TEXT NOT NULL,
+  last_modified TEXT NOT NULL,
+  link TEXT NOT NULL,
+  body BLOB NOT NULL,
+  stored_at_unix INTEGER NOT NULL
+);
+
+ALTER TABLE sync_runs ADD COLUMN cached_responses INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+ALTER TABLE sync_runs ADD COLUMN rate_limit_limit INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE sync_runs ADD COLUMN rate_limit_remaining INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE sync_runs ADD COLUMN rate_limit_reset_unix INTEGER NOT NULL DEFAULT 0;
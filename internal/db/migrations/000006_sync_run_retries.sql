@@ -0,0 +1 @@
+ALTER TABLE sync_runs ADD COLUMN retries INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS github_hosts (
+  host TEXT NOT NULL PRIMARY KEY,
+  api_url TEXT NOT NULL,
+  web_url TEXT NOT NULL,
+  access_token TEXT NOT NULL DEFAULT '',
+  proxy_url TEXT NOT NULL DEFAULT '',
+  ca_file TEXT NOT NULL DEFAULT ''
+);
+
+ALTER TABLE pull_requests ADD COLUMN html_url TEXT NOT NULL DEFAULT '';
+
+-- Every repository tracked so far lives on github.com.
+UPDATE pull_requests
+SET html_url = 'https://github.com/' || repository || '/pull/' || number
+WHERE html_url = '';
@@ -0,0 +1 @@
+ALTER TABLE github_hosts ADD COLUMN backend TEXT NOT NULL DEFAULT 'rest';
@@ -0,0 +1,2 @@
+ALTER TABLE pull_requests ADD COLUMN head_sha TEXT NOT NULL DEFAULT '';
+ALTER TABLE pull_requests ADD COLUMN last_fetched_unix INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE pull_requests ADD COLUMN reviews TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE pull_requests ADD COLUMN review_decision TEXT NOT NULL DEFAULT '';
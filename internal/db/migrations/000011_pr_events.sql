@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS pr_events (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  repository TEXT NOT NULL,
+  number INTEGER NOT NULL,
+  kind TEXT NOT NULL,
+  actor TEXT NOT NULL DEFAULT '',
+  occurred_at_unix INTEGER NOT NULL,
+  from_value TEXT NOT NULL DEFAULT '',
+  to_value TEXT NOT NULL DEFAULT '',
+  recorded_at_unix INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS pr_events_repository_number ON pr_events (repository, number);
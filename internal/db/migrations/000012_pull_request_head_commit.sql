@@ -0,0 +1 @@
+ALTER TABLE pull_requests ADD COLUMN head_commit_author TEXT NOT NULL DEFAULT '';
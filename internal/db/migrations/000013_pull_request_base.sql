@@ -0,0 +1,3 @@
+ALTER TABLE pull_requests ADD COLUMN base_sha TEXT NOT NULL DEFAULT '';
+ALTER TABLE pull_requests ADD COLUMN commit_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE pr_events ADD COLUMN count INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+ALTER TABLE pull_requests ADD COLUMN last_comment_author TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS ignored_commenters (
+  login TEXT NOT NULL PRIMARY KEY
+);
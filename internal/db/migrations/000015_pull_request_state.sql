@@ -0,0 +1,6 @@
+ALTER TABLE pull_requests ADD COLUMN state TEXT NOT NULL DEFAULT 'open';
+ALTER TABLE pull_requests ADD COLUMN closed_at_unix INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE pull_requests ADD COLUMN merged_at_unix INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE pull_requests ADD COLUMN merged_by TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS pull_requests_state_closed_at ON pull_requests (state, closed_at_unix);
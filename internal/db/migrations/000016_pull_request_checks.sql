@@ -0,0 +1,2 @@
+ALTER TABLE pull_requests ADD COLUMN checks TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE pr_events ADD COLUMN subject TEXT NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE pull_requests ADD COLUMN base_ref TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+ALTER TABLE pull_requests ADD COLUMN mergeable BOOLEAN;
+ALTER TABLE pull_requests ADD COLUMN mergeable_state TEXT NOT NULL DEFAULT '';
+ALTER TABLE pull_requests ADD COLUMN behind_by INTEGER NOT NULL DEFAULT 0;
@@ -12,9 +12,29 @@ INSERT INTO pull_requests (
   last_commit_unix,
   last_ci_status_update_unix,
   last_acknowledged_unix,
-  requested_reviewers
+  requested_reviewers,
+  last_synced_unix,
+  sync_error,
+  html_url,
+  head_sha,
+  last_fetched_unix,
+  reviews,
+  review_decision,
+  head_commit_author,
+  base_sha,
+  commit_count,
+  last_comment_author,
+  state,
+  closed_at_unix,
+  merged_at_unix,
+  merged_by,
+  checks,
+  base_ref,
+  mergeable,
+  mergeable_state,
+  behind_by
 ) VALUES (
-  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
 )
 ON CONFLICT(repository, number) DO UPDATE SET
   title = excluded.title,
@@ -27,7 +47,27 @@ ON CONFLICT(repository, number) DO UPDATE SET
   last_commit_unix = excluded.last_commit_unix,
   last_ci_status_update_unix = excluded.last_ci_status_update_unix,
   last_acknowledged_unix = excluded.last_acknowledged_unix,
-  requested_reviewers = excluded.requested_reviewers;
+  requested_reviewers = excluded.requested_reviewers,
+  last_synced_unix = excluded.last_synced_unix,
+  sync_error = excluded.sync_error,
+  html_url = excluded.html_url,
+  head_sha = excluded.head_sha,
+  last_fetched_unix = excluded.last_fetched_unix,
+  reviews = excluded.reviews,
+  review_decision = excluded.review_decision,
+  head_commit_author = excluded.head_commit_author,
+  base_sha = excluded.base_sha,
+  commit_count = excluded.commit_count,
+  last_comment_author = excluded.last_comment_author,
+  state = excluded.state,
+  closed_at_unix = excluded.closed_at_unix,
+  merged_at_unix = excluded.merged_at_unix,
+  merged_by = excluded.merged_by,
+  checks = excluded.checks,
+  base_ref = excluded.base_ref,
+  mergeable = excluded.mergeable,
+  mergeable_state = excluded.mergeable_state,
+  behind_by = excluded.behind_by;
 
 -- name: GetAllPullRequests :many
 SELECT
@@ -43,8 +83,29 @@ SELECT
   last_commit_unix,
   last_ci_status_update_unix,
   last_acknowledged_unix,
-  requested_reviewers
-FROM pull_requests;
+  requested_reviewers,
+  last_synced_unix,
+  sync_error,
+  html_url,
+  head_sha,
+  last_fetched_unix,
+  reviews,
+  review_decision,
+  head_commit_author,
+  base_sha,
+  commit_count,
+  last_comment_author,
+  state,
+  closed_at_unix,
+  merged_at_unix,
+  merged_by,
+  checks,
+  base_ref,
+  mergeable,
+  mergeable_state,
+  behind_by
+FROM pull_requests
+WHERE state = 'open';
 
 -- name: GetPullRequestByRepoAndNumber :one
 SELECT
@@ -60,7 +121,27 @@ SELECT
   last_commit_unix,
   last_ci_status_update_unix,
   last_acknowledged_unix,
-  requested_reviewers
+  requested_reviewers,
+  last_synced_unix,
+  sync_error,
+  html_url,
+  head_sha,
+  last_fetched_unix,
+  reviews,
+  review_decision,
+  head_commit_author,
+  base_sha,
+  commit_count,
+  last_comment_author,
+  state,
+  closed_at_unix,
+  merged_at_unix,
+  merged_by,
+  checks,
+  base_ref,
+  mergeable,
+  mergeable_state,
+  behind_by
 FROM pull_requests
 WHERE repository = ?
 AND number = ?
@@ -72,6 +153,17 @@ INSERT INTO tracked_authors (author) VALUES (?);
 -- name: GetTrackedAuthors :many
 SELECT author FROM tracked_authors;
 
+-- name: SaveIgnoredCommenter :exec
+INSERT OR IGNORE INTO ignored_commenters (login) VALUES (?);
+
+-- name: GetIgnoredCommenters :many
+SELECT login FROM ignored_commenters
+ORDER BY login;
+
+-- name: DeleteIgnoredCommenter :exec
+DELETE FROM ignored_commenters
+WHERE login = ?;
+
 -- name: SaveTrackedRepository :exec
 INSERT INTO tracked_repositories (repository) VALUES (?);
 
@@ -102,12 +194,262 @@ SELECT
   last_commit_unix,
   last_ci_status_update_unix,
   last_acknowledged_unix,
-  requested_reviewers
+  requested_reviewers,
+  last_synced_unix,
+  sync_error,
+  html_url,
+  head_sha,
+  last_fetched_unix,
+  reviews,
+  review_decision,
+  head_commit_author,
+  base_sha,
+  commit_count,
+  last_comment_author,
+  state,
+  closed_at_unix,
+  merged_at_unix,
+  merged_by,
+  checks,
+  base_ref,
+  mergeable,
+  mergeable_state,
+  behind_by
 FROM pull_requests
-WHERE repository = ?;
+WHERE repository = ?
+AND state = 'open';
+
+-- name: GetPullRequestsByState :many
+SELECT
+  number,
+  title,
+  repository,
+  author,
+  draft,
+  created_at_unix,
+  updated_at_unix,
+  ci_status,
+  last_comment_unix,
+  last_commit_unix,
+  last_ci_status_update_unix,
+  last_acknowledged_unix,
+  requested_reviewers,
+  last_synced_unix,
+  sync_error,
+  html_url,
+  head_sha,
+  last_fetched_unix,
+  reviews,
+  review_decision,
+  head_commit_author,
+  base_sha,
+  commit_count,
+  last_comment_author,
+  state,
+  closed_at_unix,
+  merged_at_unix,
+  merged_by,
+  checks,
+  base_ref,
+  mergeable,
+  mergeable_state,
+  behind_by
+FROM pull_requests
+WHERE state = ?
+ORDER BY closed_at_unix DESC
+LIMIT ?;
 
 -- name: DeletePrByRepositoryAndNumber :exec
 DELETE FROM pull_requests
 WHERE repository = ?
 AND number= ?;
 
+
+-- name: MarkPullRequestSynced :exec
+UPDATE pull_requests
+SET last_synced_unix = ?,
+  sync_error = '',
+  updated_at_unix = ?,
+  head_sha = ?,
+  last_fetched_unix = ?,
+  checks = ?,
+  mergeable = ?,
+  mergeable_state = ?,
+  behind_by = ?
+WHERE repository = ?
+AND number = ?;
+
+-- name: MarkPullRequestSyncFailed :exec
+UPDATE pull_requests
+SET sync_error = ?
+WHERE repository = ?
+AND number = ?;
+
+-- name: CreateSyncRun :one
+INSERT INTO sync_runs (
+  started_at_unix,
+  finished_at_unix,
+  outcome,
+  new_count,
+  updated_count,
+  removed_count,
+  stale_count,
+  api_calls,
+  error,
+  cached_responses,
+  rate_limit_limit,
+  rate_limit_remaining,
+  rate_limit_reset_unix,
+  retries
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+RETURNING id;
+
+-- name: CreateSyncRunRepository :exec
+INSERT INTO sync_run_repositories (
+  sync_run_id,
+  repository,
+  outcome,
+  new_count,
+  updated_count,
+  removed_count,
+  stale_count,
+  duration_ms,
+  error
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?
+);
+
+-- name: GetRecentSyncRuns :many
+SELECT
+  id,
+  started_at_unix,
+  finished_at_unix,
+  outcome,
+  new_count,
+  updated_count,
+  removed_count,
+  stale_count,
+  api_calls,
+  error,
+  cached_responses,
+  rate_limit_limit,
+  rate_limit_remaining,
+  rate_limit_reset_unix,
+  retries
+FROM sync_runs
+ORDER BY started_at_unix DESC, id DESC
+LIMIT ?;
+
+-- name: GetSyncRunRepositories :many
+SELECT
+  id,
+  sync_run_id,
+  repository,
+  outcome,
+  new_count,
+  updated_count,
+  removed_count,
+  stale_count,
+  duration_ms,
+  error
+FROM sync_run_repositories
+WHERE sync_run_id = ?
+ORDER BY id;
+
+-- name: GetCachedResponse :one
+SELECT etag, last_modified, link, body
+FROM http_cache
+WHERE url = ?
+LIMIT 1;
+
+-- name: UpsertCachedResponse :exec
+INSERT INTO http_cache (
+  url,
+  etag,
+  last_modified,
+  link,
+  body,
+  stored_at_unix
+) VALUES (
+  ?, ?, ?, ?, ?, ?
+)
+ON CONFLICT(url) DO UPDATE SET
+  etag = excluded.etag,
+  last_modified = excluded.last_modified,
+  link = excluded.link,
+  body = excluded.body,
+  stored_at_unix = excluded.stored_at_unix;
+
+-- name: DeleteCachedResponsesStoredBefore :exec
+DELETE FROM http_cache
+WHERE stored_at_unix < ?;
+
+-- name: GetGitHubHosts :many
+SELECT host, api_url, web_url, access_token, proxy_url, ca_file, backend
+FROM github_hosts
+ORDER BY host;
+
+-- name: UpsertGitHubHost :exec
+INSERT INTO github_hosts (
+  host,
+  api_url,
+  web_url,
+  access_token,
+  proxy_url,
+  ca_file,
+  backend
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?
+)
+ON CONFLICT(host) DO UPDATE SET
+  api_url = excluded.api_url,
+  web_url = excluded.web_url,
+  access_token = excluded.access_token,
+  proxy_url = excluded.proxy_url,
+  ca_file = excluded.ca_file,
+  backend = excluded.backend;
+
+-- name: DeleteGitHubHost :exec
+DELETE FROM github_hosts
+WHERE host = ?;
+
+-- name: CreatePrEvent :exec
+INSERT INTO pr_events (
+  repository,
+  number,
+  kind,
+  actor,
+  occurred_at_unix,
+  from_value,
+  to_value,
+  recorded_at_unix,
+  count,
+  subject
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+);
+
+-- name: GetPrEvents :many
+SELECT
+  id,
+  repository,
+  number,
+  kind,
+  actor,
+  occurred_at_unix,
+  from_value,
+  to_value,
+  recorded_at_unix,
+  count,
+  subject
+FROM pr_events
+WHERE repository = ?
+AND number = ?
+ORDER BY occurred_at_unix, id;
+
+-- name: DeletePrEvents :exec
+DELETE FROM pr_events
+WHERE repository = ?
+AND number = ?;
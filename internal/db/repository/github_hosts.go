@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+
+	"git.rileymathews.com/riley/pr-tracker/internal/db/gen"
+	"git.rileymathews.com/riley/pr-tracker/internal/models"
+)
+
+func (repository *DatabaseRepository) GetGitHubHosts(ctx context.Context) ([]models.GitHubHost, error) {
+	rows, err := repository.queries.GetGitHubHosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := make([]models.GitHubHost, 0, len(rows))
+	for _, row := range rows {
+		hosts = append(hosts, models.GitHubHost{
+			Host:        row.Host,
+			APIURL:      row.ApiUrl,
+			WebURL:      row.WebUrl,
+			AccessToken: row.AccessToken,
+			ProxyURL:    row.ProxyUrl,
+			CAFile:      row.CaFile,
+			Backend:     row.Backend,
+		})
+	}
+
+	return hosts, nil
+}
+
+// SaveGitHubHost adds a host or replaces the settings of an existing one.
+func (repository *DatabaseRepository) SaveGitHubHost(ctx context.Context, host models.GitHubHost) error {
+	return repository.queries.UpsertGitHubHost(ctx, gen.UpsertGitHubHostParams{
+		Host:        host.Host,
+		ApiUrl:      host.APIURL,
+		WebUrl:      host.WebURL,
+		AccessToken: host.AccessToken,
+		ProxyUrl:    host.ProxyURL,
+		CaFile:      host.CAFile,
+		Backend:     host.Backend,
+	})
+}
+
+func (repository *DatabaseRepository) DeleteGitHubHost(ctx context.Context, host string) error {
+	return repository.queries.DeleteGitHubHost(ctx, host)
+}
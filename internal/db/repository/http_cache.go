@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"git.rileymathews.com/riley/pr-tracker/internal/db/gen"
+	"git.rileymathews.com/riley/pr-tracker/internal/github"
+)
+
+// GetCachedResponse and SaveCachedResponse implement github.ResponseCache.
+func (repository *DatabaseRepository) GetCachedResponse(ctx context.Context, url string) (*github.CachedResponse, error) {
+	row, err := repository.queries.GetCachedResponse(ctx, url)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &github.CachedResponse{
+		ETag:         row.Etag,
+		LastModified: row.LastModified,
+		Link:         row.Link,
+		Body:         row.Body,
+	}, nil
+}
+
+func (repository *DatabaseRepository) SaveCachedResponse(ctx context.Context, url string, response *github.CachedResponse) error {
+	return repository.queries.UpsertCachedResponse(ctx, gen.UpsertCachedResponseParams{
+		Url:          url,
+		Etag:         response.ETag,
+		LastModified: response.LastModified,
+		Link:         response.Link,
+		Body:         response.Body,
+		StoredAtUnix: time.Now().Unix(),
+	})
+}
+
+// PruneCachedResponses drops cached responses that have not been refreshed
+// since before, which mostly means URLs for pull requests that have closed.
+func (repository *DatabaseRepository) PruneCachedResponses(ctx context.Context, before time.Time) error {
+	return repository.queries.DeleteCachedResponsesStoredBefore(ctx, before.Unix())
+}
@@ -15,24 +15,66 @@ import (
 )
 
 type DatabaseRepository struct {
+	db      *sql.DB
 	queries *gen.Queries
-	ctx     context.Context
 }
 
-func New(queries *gen.Queries, context context.Context) *DatabaseRepository {
+func New(dbConn *sql.DB) *DatabaseRepository {
 	return &DatabaseRepository{
-		queries: queries,
-		ctx:     context,
+		db:      dbConn,
+		queries: gen.New(dbConn),
 	}
 }
 
-func (repository *DatabaseRepository) SavePr(internalPR *models.PullRequest) error {
+// WithTx runs fn against a repository bound to a single transaction. The
+// transaction is committed when fn returns nil and rolled back otherwise, so
+// callers never observe a partially applied set of writes.
+func (repository *DatabaseRepository) WithTx(ctx context.Context, fn func(txRepository *DatabaseRepository) error) error {
+	tx, err := repository.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	txRepository := &DatabaseRepository{
+		db:      repository.db,
+		queries: repository.queries.WithTx(tx),
+	}
+	if err := fn(txRepository); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rollbackErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (repository *DatabaseRepository) SavePr(ctx context.Context, internalPR *models.PullRequest) error {
 	reviewersJSON, err := json.Marshal(internalPR.RequestedReviewers)
 	if err != nil {
 		return fmt.Errorf("marshal requested_reviewers: %w", err)
 	}
 
-	return repository.queries.UpsertPullRequest(repository.ctx, gen.UpsertPullRequestParams{
+	reviewsJSON, err := json.Marshal(storedReviewsFromModels(internalPR.Reviews))
+	if err != nil {
+		return fmt.Errorf("marshal reviews: %w", err)
+	}
+
+	checksJSON, err := json.Marshal(storedChecksFromModels(internalPR.Checks))
+	if err != nil {
+		return fmt.Errorf("marshal checks: %w", err)
+	}
+
+	state := internalPR.State
+	if state == "" {
+		state = models.PullRequestStateOpen
+	}
+
+	return repository.queries.UpsertPullRequest(ctx, gen.UpsertPullRequestParams{
 		Number:                 int64(internalPR.Number),
 		Title:                  internalPR.Title,
 		Repository:             internalPR.Repository,
@@ -46,96 +88,115 @@ func (repository *DatabaseRepository) SavePr(internalPR *models.PullRequest) err
 		LastCiStatusUpdateUnix: internalPR.LastCiStatusUpdateAt.Unix(),
 		LastAcknowledgedUnix:   timeToNullInt64(internalPR.LastAcknowledgedAt),
 		RequestedReviewers:     string(reviewersJSON),
+		LastSyncedUnix:         timeToUnix(internalPR.LastSyncedAt),
+		SyncError:              internalPR.SyncError,
+		HtmlUrl:                internalPR.HTMLURL,
+		HeadSha:                internalPR.HeadSHA,
+		LastFetchedUnix:        timeToUnix(internalPR.LastFetchedAt),
+		Reviews:                string(reviewsJSON),
+		ReviewDecision:         string(internalPR.ReviewDecision),
+		HeadCommitAuthor:       internalPR.HeadCommitAuthor,
+		BaseRef:                internalPR.BaseRef,
+		Mergeable:              boolToNullBool(internalPR.Mergeable),
+		MergeableState:         internalPR.MergeableState,
+		BehindBy:               int64(internalPR.BehindBy),
+		BaseSha:                internalPR.BaseSHA,
+		CommitCount:            int64(internalPR.CommitCount),
+		LastCommentAuthor:      internalPR.LastCommentAuthor,
+		State:                  string(state),
+		ClosedAtUnix:           timeToUnix(internalPR.ClosedAt),
+		MergedAtUnix:           timeToUnix(internalPR.MergedAt),
+		MergedBy:               internalPR.MergedBy,
+		Checks:                 string(checksJSON),
+	})
+}
+
+// MarkPrSynced records that a pull request was synced successfully at
+// syncedAt, clearing any previous sync error. The head commit, updated_at and
+// fetch time are stored as well, even when nothing else about the pull request
+// changed, so the next incremental sync compares against the latest listing.
+// So are its checks, which can finish without changing the overall CI status,
+// and its mergeability, which only raises an event when it gets worse.
+func (repository *DatabaseRepository) MarkPrSynced(ctx context.Context, pr *models.PullRequest, syncedAt time.Time) error {
+	checksJSON, err := json.Marshal(storedChecksFromModels(pr.Checks))
+	if err != nil {
+		return fmt.Errorf("marshal checks: %w", err)
+	}
+
+	return repository.queries.MarkPullRequestSynced(ctx, gen.MarkPullRequestSyncedParams{
+		LastSyncedUnix:  syncedAt.Unix(),
+		UpdatedAtUnix:   pr.UpdatedAt.Unix(),
+		HeadSha:         pr.HeadSHA,
+		LastFetchedUnix: timeToUnix(pr.LastFetchedAt),
+		Checks:          string(checksJSON),
+		Mergeable:       boolToNullBool(pr.Mergeable),
+		MergeableState:  pr.MergeableState,
+		BehindBy:        int64(pr.BehindBy),
+		Repository:      pr.Repository,
+		Number:          int64(pr.Number),
 	})
 }
 
-func (repository *DatabaseRepository) DeletePr(repoName string, prNumber int) error {
-	return repository.queries.DeletePrByRepositoryAndNumber(repository.ctx, gen.DeletePrByRepositoryAndNumberParams{
+// MarkPrSyncFailed records why a pull request could not be fetched. The
+// rest of the row, including its last successful sync time, is left as is.
+func (repository *DatabaseRepository) MarkPrSyncFailed(ctx context.Context, repoName string, prNumber int, syncErr string) error {
+	return repository.queries.MarkPullRequestSyncFailed(ctx, gen.MarkPullRequestSyncFailedParams{
+		SyncError:  syncErr,
 		Repository: repoName,
 		Number:     int64(prNumber),
 	})
 }
 
-func (repository *DatabaseRepository) GetPrsByRepository(repoName string) ([]*models.PullRequest, error) {
-	rows, err := repository.queries.GetPrsByRepository(repository.ctx, repoName)
-	if err != nil {
-		return nil, err
+// DeletePr removes a pull request along with its event log. Call it inside
+// WithTx so the two go together.
+func (repository *DatabaseRepository) DeletePr(ctx context.Context, repoName string, prNumber int) error {
+	if err := repository.queries.DeletePrEvents(ctx, gen.DeletePrEventsParams{
+		Repository: repoName,
+		Number:     int64(prNumber),
+	}); err != nil {
+		return fmt.Errorf("delete events: %w", err)
 	}
 
-	prs := make([]*models.PullRequest, 0, len(rows))
-	for _, row := range rows {
-		var lastAcknowledgedAt *time.Time
-		if row.LastAcknowledgedUnix.Valid {
-			t := time.Unix(row.LastAcknowledgedUnix.Int64, 0).UTC()
-			lastAcknowledgedAt = &t
-		}
-
-		var reviewerLogins []string
-		if err := json.Unmarshal([]byte(row.RequestedReviewers), &reviewerLogins); err != nil {
-			return nil, fmt.Errorf("unmarshal requested_reviewers for pr %d: %w", row.Number, err)
-		}
+	return repository.queries.DeletePrByRepositoryAndNumber(ctx, gen.DeletePrByRepositoryAndNumberParams{
+		Repository: repoName,
+		Number:     int64(prNumber),
+	})
+}
 
-		prs = append(prs, &models.PullRequest{
-			Number:               int(row.Number),
-			Title:                row.Title,
-			Repository:           row.Repository,
-			Author:               row.Author,
-			Draft:                row.Draft,
-			CreatedAt:            time.Unix(row.CreatedAtUnix, 0).UTC(),
-			UpdatedAt:            time.Unix(row.UpdatedAtUnix, 0).UTC(),
-			CiStatus:             models.CiStatus(row.CiStatus),
-			LastCommentAt:        time.Unix(row.LastCommentUnix, 0).UTC(),
-			LastCommitAt:         time.Unix(row.LastCommitUnix, 0).UTC(),
-			LastCiStatusUpdateAt: time.Unix(row.LastCiStatusUpdateUnix, 0).UTC(),
-			LastAcknowledgedAt:   lastAcknowledgedAt,
-			RequestedReviewers:   reviewerLogins,
-		})
+func (repository *DatabaseRepository) GetPrsByRepository(ctx context.Context, repoName string) ([]*models.PullRequest, error) {
+	rows, err := repository.queries.GetPrsByRepository(ctx, repoName)
+	if err != nil {
+		return nil, err
 	}
 
-	return prs, nil
+	return pullRequestsFromRows(rows)
 }
 
-func (repository *DatabaseRepository) GetAllPrs() ([]*models.PullRequest, error) {
-	rows, err := repository.queries.GetAllPullRequests(repository.ctx)
+func (repository *DatabaseRepository) GetAllPrs(ctx context.Context) ([]*models.PullRequest, error) {
+	rows, err := repository.queries.GetAllPullRequests(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	prs := make([]*models.PullRequest, 0, len(rows))
-	for _, row := range rows {
-		var lastAcknowledgedAt *time.Time
-		if row.LastAcknowledgedUnix.Valid {
-			t := time.Unix(row.LastAcknowledgedUnix.Int64, 0).UTC()
-			lastAcknowledgedAt = &t
-		}
-
-		var reviewerLogins []string
-		if err := json.Unmarshal([]byte(row.RequestedReviewers), &reviewerLogins); err != nil {
-			return nil, fmt.Errorf("unmarshal requested_reviewers for pr %d: %w", row.Number, err)
-		}
+	return pullRequestsFromRows(rows)
+}
 
-		prs = append(prs, &models.PullRequest{
-			Number:               int(row.Number),
-			Title:                row.Title,
-			Repository:           row.Repository,
-			Author:               row.Author,
-			Draft:                row.Draft,
-			CreatedAt:            time.Unix(row.CreatedAtUnix, 0).UTC(),
-			UpdatedAt:            time.Unix(row.UpdatedAtUnix, 0).UTC(),
-			CiStatus:             models.CiStatus(row.CiStatus),
-			LastCommentAt:        time.Unix(row.LastCommentUnix, 0).UTC(),
-			LastCommitAt:         time.Unix(row.LastCommitUnix, 0).UTC(),
-			LastCiStatusUpdateAt: time.Unix(row.LastCiStatusUpdateUnix, 0).UTC(),
-			LastAcknowledgedAt:   lastAcknowledgedAt,
-			RequestedReviewers:   reviewerLogins,
-		})
+// GetPrsByState returns up to limit pull requests in state, most recently
+// closed first. GetAllPrs and GetPrsByRepository only return open ones.
+func (repository *DatabaseRepository) GetPrsByState(ctx context.Context, state models.PullRequestState, limit int) ([]*models.PullRequest, error) {
+	rows, err := repository.queries.GetPullRequestsByState(ctx, gen.GetPullRequestsByStateParams{
+		State: string(state),
+		Limit: int64(limit),
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return prs, nil
+	return pullRequestsFromRows(rows)
 }
 
-func (repository *DatabaseRepository) GetUser() (*models.User, error) {
-	rows, err := repository.queries.GetUsers(repository.ctx)
+func (repository *DatabaseRepository) GetUser(ctx context.Context) (*models.User, error) {
+	rows, err := repository.queries.GetUsers(ctx)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -158,15 +219,15 @@ func (repository *DatabaseRepository) GetUser() (*models.User, error) {
 	}, nil
 }
 
-func (repository *DatabaseRepository) SaveUser(user *models.User) error {
-	return repository.queries.SaveUser(repository.ctx, gen.SaveUserParams{
+func (repository *DatabaseRepository) SaveUser(ctx context.Context, user *models.User) error {
+	return repository.queries.SaveUser(ctx, gen.SaveUserParams{
 		Username:    user.Username,
 		AccessToken: user.AccessToken,
 	})
 }
 
-func (repository *DatabaseRepository) GetPr(repoName string, prNumber int) (*models.PullRequest, error) {
-	row, err := repository.queries.GetPullRequestByRepoAndNumber(repository.ctx, gen.GetPullRequestByRepoAndNumberParams{
+func (repository *DatabaseRepository) GetPr(ctx context.Context, repoName string, prNumber int) (*models.PullRequest, error) {
+	row, err := repository.queries.GetPullRequestByRepoAndNumber(ctx, gen.GetPullRequestByRepoAndNumberParams{
 		Repository: repoName,
 		Number:     int64(prNumber),
 	})
@@ -178,6 +239,23 @@ func (repository *DatabaseRepository) GetPr(repoName string, prNumber int) (*mod
 		return nil, err
 	}
 
+	return pullRequestFromRow(row)
+}
+
+func pullRequestsFromRows(rows []gen.PullRequest) ([]*models.PullRequest, error) {
+	prs := make([]*models.PullRequest, 0, len(rows))
+	for _, row := range rows {
+		pr, err := pullRequestFromRow(row)
+		if err != nil {
+			return nil, err
+		}
+		prs = append(prs, pr)
+	}
+
+	return prs, nil
+}
+
+func pullRequestFromRow(row gen.PullRequest) (*models.PullRequest, error) {
 	var lastAcknowledgedAt *time.Time
 	if row.LastAcknowledgedUnix.Valid {
 		t := time.Unix(row.LastAcknowledgedUnix.Int64, 0).UTC()
@@ -189,6 +267,16 @@ func (repository *DatabaseRepository) GetPr(repoName string, prNumber int) (*mod
 		return nil, fmt.Errorf("unmarshal requested_reviewers for pr %d: %w", row.Number, err)
 	}
 
+	var reviews []storedReview
+	if err := json.Unmarshal([]byte(row.Reviews), &reviews); err != nil {
+		return nil, fmt.Errorf("unmarshal reviews for pr %d: %w", row.Number, err)
+	}
+
+	var checks []storedCheck
+	if err := json.Unmarshal([]byte(row.Checks), &checks); err != nil {
+		return nil, fmt.Errorf("unmarshal checks for pr %d: %w", row.Number, err)
+	}
+
 	return &models.PullRequest{
 		Number:               int(row.Number),
 		Title:                row.Title,
@@ -203,27 +291,156 @@ func (repository *DatabaseRepository) GetPr(repoName string, prNumber int) (*mod
 		LastCiStatusUpdateAt: time.Unix(row.LastCiStatusUpdateUnix, 0).UTC(),
 		LastAcknowledgedAt:   lastAcknowledgedAt,
 		RequestedReviewers:   reviewerLogins,
+		LastSyncedAt:         unixToTime(row.LastSyncedUnix),
+		SyncError:            row.SyncError,
+		HTMLURL:              row.HtmlUrl,
+		HeadSHA:              row.HeadSha,
+		HeadCommitAuthor:     row.HeadCommitAuthor,
+		BaseRef:              row.BaseRef,
+		Mergeable:            nullBoolToBool(row.Mergeable),
+		MergeableState:       row.MergeableState,
+		BehindBy:             int(row.BehindBy),
+		BaseSHA:              row.BaseSha,
+		CommitCount:          int(row.CommitCount),
+		LastCommentAuthor:    row.LastCommentAuthor,
+		State:                models.PullRequestState(row.State),
+		ClosedAt:             unixToTime(row.ClosedAtUnix),
+		MergedAt:             unixToTime(row.MergedAtUnix),
+		MergedBy:             row.MergedBy,
+		LastFetchedAt:        unixToTime(row.LastFetchedUnix),
+		Reviews:              reviewsFromStored(reviews),
+		Checks:               checksFromStored(checks),
+		ReviewDecision:       models.ReviewDecision(row.ReviewDecision),
 	}, nil
 }
 
-func (repository *DatabaseRepository) GetTrackedAuthors() ([]string, error) {
-	return repository.queries.GetTrackedAuthors(repository.ctx)
+// storedReview is how a review is kept in the reviews JSON column.
+type storedReview struct {
+	Reviewer        string `json:"reviewer"`
+	State           string `json:"state"`
+	SubmittedAtUnix int64  `json:"submitted_at_unix"`
 }
 
-func (repository *DatabaseRepository) SaveTrackedAuthor(author string) error {
-	return repository.queries.SaveTrackedAuthor(repository.ctx, author)
+func storedReviewsFromModels(reviews []models.Review) []storedReview {
+	stored := make([]storedReview, 0, len(reviews))
+	for _, review := range reviews {
+		stored = append(stored, storedReview{
+			Reviewer:        review.Reviewer,
+			State:           string(review.State),
+			SubmittedAtUnix: timeToUnix(review.SubmittedAt),
+		})
+	}
+	return stored
 }
 
-func (repository *DatabaseRepository) GetTrackedRepositories() ([]string, error) {
-	return repository.queries.GetTrackedRepositories(repository.ctx)
+func reviewsFromStored(stored []storedReview) []models.Review {
+	reviews := make([]models.Review, 0, len(stored))
+	for _, review := range stored {
+		reviews = append(reviews, models.Review{
+			Reviewer:    review.Reviewer,
+			State:       models.ReviewState(review.State),
+			SubmittedAt: unixToTime(review.SubmittedAtUnix),
+		})
+	}
+	return reviews
 }
 
-func (repository *DatabaseRepository) SaveTrackedRepository(repo string) error {
-	return repository.queries.SaveTrackedRepository(repository.ctx, repo)
+// storedCheck is how a check is kept in the checks JSON column.
+type storedCheck struct {
+	Name            string `json:"name"`
+	App             string `json:"app,omitempty"`
+	Status          string `json:"status"`
+	Conclusion      string `json:"conclusion,omitempty"`
+	DetailsURL      string `json:"details_url,omitempty"`
+	StartedAtUnix   int64  `json:"started_at_unix,omitempty"`
+	CompletedAtUnix int64  `json:"completed_at_unix,omitempty"`
+	Required        bool   `json:"required,omitempty"`
 }
 
-func (repository *DatabaseRepository) DeleteTrackedRepository(repo string) error {
-	return repository.queries.DeleteTrackedRepository(repository.ctx, repo)
+func storedChecksFromModels(checks []models.Check) []storedCheck {
+	stored := make([]storedCheck, 0, len(checks))
+	for _, check := range checks {
+		stored = append(stored, storedCheck{
+			Name:            check.Name,
+			App:             check.App,
+			Status:          check.Status,
+			Conclusion:      check.Conclusion,
+			DetailsURL:      check.DetailsURL,
+			StartedAtUnix:   timeToUnix(check.StartedAt),
+			CompletedAtUnix: timeToUnix(check.CompletedAt),
+			Required:        check.Required,
+		})
+	}
+	return stored
+}
+
+func checksFromStored(stored []storedCheck) []models.Check {
+	checks := make([]models.Check, 0, len(stored))
+	for _, check := range stored {
+		checks = append(checks, models.Check{
+			Name:        check.Name,
+			App:         check.App,
+			Status:      check.Status,
+			Conclusion:  check.Conclusion,
+			DetailsURL:  check.DetailsURL,
+			StartedAt:   unixToTime(check.StartedAtUnix),
+			CompletedAt: unixToTime(check.CompletedAtUnix),
+			Required:    check.Required,
+		})
+	}
+	return checks
+}
+
+func (repository *DatabaseRepository) GetTrackedAuthors(ctx context.Context) ([]string, error) {
+	return repository.queries.GetTrackedAuthors(ctx)
+}
+
+func (repository *DatabaseRepository) SaveTrackedAuthor(ctx context.Context, author string) error {
+	return repository.queries.SaveTrackedAuthor(ctx, author)
+}
+
+// GetIgnoredCommenters returns the logins whose comments don't count as new
+// activity on a pull request.
+func (repository *DatabaseRepository) GetIgnoredCommenters(ctx context.Context) ([]string, error) {
+	return repository.queries.GetIgnoredCommenters(ctx)
+}
+
+func (repository *DatabaseRepository) SaveIgnoredCommenter(ctx context.Context, login string) error {
+	return repository.queries.SaveIgnoredCommenter(ctx, login)
+}
+
+func (repository *DatabaseRepository) DeleteIgnoredCommenter(ctx context.Context, login string) error {
+	return repository.queries.DeleteIgnoredCommenter(ctx, login)
+}
+
+func (repository *DatabaseRepository) GetTrackedRepositories(ctx context.Context) ([]string, error) {
+	return repository.queries.GetTrackedRepositories(ctx)
+}
+
+func (repository *DatabaseRepository) SaveTrackedRepository(ctx context.Context, repo string) error {
+	return repository.queries.SaveTrackedRepository(ctx, repo)
+}
+
+func (repository *DatabaseRepository) DeleteTrackedRepository(ctx context.Context, repo string) error {
+	return repository.queries.DeleteTrackedRepository(ctx, repo)
+}
+
+// timeToUnix and unixToTime store the zero time as 0 so "never" survives a
+// round trip through the database instead of turning into the Unix epoch.
+func timeToUnix(value time.Time) int64 {
+	if value.IsZero() {
+		return 0
+	}
+
+	return value.Unix()
+}
+
+func unixToTime(value int64) time.Time {
+	if value == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(value, 0).UTC()
 }
 
 func timeToNullInt64(value *time.Time) sql.NullInt64 {
@@ -234,6 +451,37 @@ func timeToNullInt64(value *time.Time) sql.NullInt64 {
 	return sql.NullInt64{Int64: value.Unix(), Valid: true}
 }
 
+// boolToNullBool and nullBoolToBool store a nil *bool as NULL, for values
+// GitHub may not have worked out yet.
+func boolToNullBool(value *bool) sql.NullBool {
+	if value == nil {
+		return sql.NullBool{}
+	}
+
+	return sql.NullBool{Bool: *value, Valid: true}
+}
+
+func nullBoolToBool(value sql.NullBool) *bool {
+	if !value.Valid {
+		return nil
+	}
+
+	return &value.Bool
+}
+
+// CopyTo writes a consistent copy of the whole database to path, which must
+// not exist yet.
+func (repository *DatabaseRepository) CopyTo(ctx context.Context, path string) error {
+	if _, err := repository.db.ExecContext(ctx, "VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("copy database to %s: %w", path, err)
+	}
+	return nil
+}
+
+// ApplyMigrations runs every migration in migrationsDir that has not been
+// applied yet, in filename order. Applied migrations are recorded in the
+// schema_migrations table so statements that are not idempotent, such as
+// ALTER TABLE, only ever run once.
 func ApplyMigrations(ctx context.Context, dbConn *sql.DB, migrationsDir string) error {
 	pattern := filepath.Join(migrationsDir, "*.sql")
 	files, err := filepath.Glob(pattern)
@@ -244,16 +492,50 @@ func ApplyMigrations(ctx context.Context, dbConn *sql.DB, migrationsDir string)
 		return fmt.Errorf("no migration files found in %s", migrationsDir)
 	}
 
+	if _, err := dbConn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+  name TEXT NOT NULL PRIMARY KEY,
+  applied_at_unix INTEGER NOT NULL
+)`); err != nil {
+		return fmt.Errorf("create schema_migrations table: %w", err)
+	}
+
 	sort.Strings(files)
 	for _, file := range files {
+		name := filepath.Base(file)
+
+		var applied int
+		if err := dbConn.QueryRowContext(ctx, "SELECT COUNT(*) FROM schema_migrations WHERE name = ?", name).Scan(&applied); err != nil {
+			return fmt.Errorf("check migration %s: %w", name, err)
+		}
+		if applied > 0 {
+			continue
+		}
+
 		sqlBytes, err := os.ReadFile(file)
 		if err != nil {
 			return fmt.Errorf("read migration file %s: %w", file, err)
 		}
-		if _, err := dbConn.ExecContext(ctx, string(sqlBytes)); err != nil {
+		if err := applyMigration(ctx, dbConn, name, string(sqlBytes)); err != nil {
 			return fmt.Errorf("execute migration file %s: %w", file, err)
 		}
 	}
 
 	return nil
 }
+
+func applyMigration(ctx context.Context, dbConn *sql.DB, name, statements string) error {
+	tx, err := dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, statements); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (name, applied_at_unix) VALUES (?, ?)", name, time.Now().Unix()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"git.rileymathews.com/riley/pr-tracker/internal/db/gen"
+	"git.rileymathews.com/riley/pr-tracker/internal/models"
+)
+
+// SavePrEvents appends events to their pull requests' event logs. recordedAt
+// is when the sync that found them ran.
+func (repository *DatabaseRepository) SavePrEvents(ctx context.Context, events []models.ChangeEvent, recordedAt time.Time) error {
+	for _, event := range events {
+		err := repository.queries.CreatePrEvent(ctx, gen.CreatePrEventParams{
+			Repository:     event.Repository,
+			Number:         int64(event.Number),
+			Kind:           string(event.Kind),
+			Actor:          event.Actor,
+			OccurredAtUnix: timeToUnix(event.At),
+			FromValue:      event.From,
+			ToValue:        event.To,
+			RecordedAtUnix: recordedAt.Unix(),
+			Count:          int64(event.Count),
+			Subject:        event.Subject,
+		})
+		if err != nil {
+			return fmt.Errorf("create %s event for pr #%d: %w", event.Kind, event.Number, err)
+		}
+	}
+
+	return nil
+}
+
+// GetPrEvents returns a pull request's event log, oldest first.
+func (repository *DatabaseRepository) GetPrEvents(ctx context.Context, repoName string, prNumber int) ([]models.ChangeEvent, error) {
+	rows, err := repository.queries.GetPrEvents(ctx, gen.GetPrEventsParams{
+		Repository: repoName,
+		Number:     int64(prNumber),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]models.ChangeEvent, 0, len(rows))
+	for _, row := range rows {
+		events = append(events, models.ChangeEvent{
+			Repository: row.Repository,
+			Number:     int(row.Number),
+			Kind:       models.ChangeEventKind(row.Kind),
+			Actor:      row.Actor,
+			At:         unixToTime(row.OccurredAtUnix),
+			From:       row.FromValue,
+			To:         row.ToValue,
+			Count:      int(row.Count),
+			Subject:    row.Subject,
+			RecordedAt: unixToTime(row.RecordedAtUnix),
+		})
+	}
+
+	return events, nil
+}
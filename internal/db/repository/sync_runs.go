@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"git.rileymathews.com/riley/pr-tracker/internal/db/gen"
+	"git.rileymathews.com/riley/pr-tracker/internal/models"
+)
+
+// SaveSyncRun stores a sync run together with its per-repository results and
+// returns the new run's id.
+func (repository *DatabaseRepository) SaveSyncRun(ctx context.Context, run *models.SyncRun) (int64, error) {
+	var runID int64
+	err := repository.WithTx(ctx, func(txRepository *DatabaseRepository) error {
+		id, err := txRepository.queries.CreateSyncRun(ctx, gen.CreateSyncRunParams{
+			StartedAtUnix:      run.StartedAt.Unix(),
+			FinishedAtUnix:     run.FinishedAt.Unix(),
+			Outcome:            string(run.Outcome),
+			NewCount:           int64(run.NewCount),
+			UpdatedCount:       int64(run.UpdatedCount),
+			RemovedCount:       int64(run.RemovedCount),
+			StaleCount:         int64(run.StaleCount),
+			ApiCalls:           run.APICalls,
+			Error:              run.Error,
+			CachedResponses:    run.CachedResponses,
+			RateLimitLimit:     int64(run.RateLimit),
+			RateLimitRemaining: int64(run.RateLimitRemaining),
+			RateLimitResetUnix: timeToUnix(run.RateLimitReset),
+			Retries:            run.Retries,
+		})
+		if err != nil {
+			return fmt.Errorf("create sync run: %w", err)
+		}
+
+		for _, repoRun := range run.Repositories {
+			err := txRepository.queries.CreateSyncRunRepository(ctx, gen.CreateSyncRunRepositoryParams{
+				SyncRunID:    id,
+				Repository:   repoRun.Repository,
+				Outcome:      string(repoRun.Outcome),
+				NewCount:     int64(repoRun.NewCount),
+				UpdatedCount: int64(repoRun.UpdatedCount),
+				RemovedCount: int64(repoRun.RemovedCount),
+				StaleCount:   int64(repoRun.StaleCount),
+				DurationMs:   repoRun.Duration.Milliseconds(),
+				Error:        repoRun.Error,
+			})
+			if err != nil {
+				return fmt.Errorf("create sync run repository %s: %w", repoRun.Repository, err)
+			}
+		}
+
+		runID = id
+		return nil
+	})
+
+	return runID, err
+}
+
+// GetRecentSyncRuns returns up to limit sync runs, newest first. The
+// per-repository results are not loaded; use GetSyncRunRepositories for those.
+func (repository *DatabaseRepository) GetRecentSyncRuns(ctx context.Context, limit int) ([]*models.SyncRun, error) {
+	rows, err := repository.queries.GetRecentSyncRuns(ctx, int64(limit))
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]*models.SyncRun, 0, len(rows))
+	for _, row := range rows {
+		runs = append(runs, &models.SyncRun{
+			ID:                 row.ID,
+			StartedAt:          time.Unix(row.StartedAtUnix, 0).UTC(),
+			FinishedAt:         time.Unix(row.FinishedAtUnix, 0).UTC(),
+			Outcome:            models.SyncOutcome(row.Outcome),
+			NewCount:           int(row.NewCount),
+			UpdatedCount:       int(row.UpdatedCount),
+			RemovedCount:       int(row.RemovedCount),
+			StaleCount:         int(row.StaleCount),
+			APICalls:           row.ApiCalls,
+			CachedResponses:    row.CachedResponses,
+			RateLimit:          int(row.RateLimitLimit),
+			RateLimitRemaining: int(row.RateLimitRemaining),
+			RateLimitReset:     unixToTime(row.RateLimitResetUnix),
+			Retries:            row.Retries,
+			Error:              row.Error,
+		})
+	}
+
+	return runs, nil
+}
+
+func (repository *DatabaseRepository) GetSyncRunRepositories(ctx context.Context, runID int64) ([]models.SyncRunRepository, error) {
+	rows, err := repository.queries.GetSyncRunRepositories(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+
+	repoRuns := make([]models.SyncRunRepository, 0, len(rows))
+	for _, row := range rows {
+		repoRuns = append(repoRuns, models.SyncRunRepository{
+			Repository:   row.Repository,
+			Outcome:      models.SyncOutcome(row.Outcome),
+			NewCount:     int(row.NewCount),
+			UpdatedCount: int(row.UpdatedCount),
+			RemovedCount: int(row.RemovedCount),
+			StaleCount:   int(row.StaleCount),
+			Duration:     time.Duration(row.DurationMs) * time.Millisecond,
+			Error:        row.Error,
+		})
+	}
+
+	return repoRuns, nil
+}
@@ -0,0 +1,90 @@
+package github
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync/atomic"
+)
+
+// CachedResponse is a previously fetched response body together with the
+// validators GitHub sent for it. Link is kept so pagination still works when
+// the body is served from the cache.
+type CachedResponse struct {
+	ETag         string
+	LastModified string
+	Link         string
+	Body         []byte
+}
+
+// ResponseCache stores successful GET responses by URL so later requests for
+// the same URL can be made conditional. GetCachedResponse returns nil when
+// nothing is cached for the URL.
+type ResponseCache interface {
+	GetCachedResponse(ctx context.Context, url string) (*CachedResponse, error)
+	SaveCachedResponse(ctx context.Context, url string, response *CachedResponse) error
+}
+
+// cachedResponseCount is the number of requests answered with
+// 304 Not Modified, which GitHub does not count against the rate limit.
+var cachedResponseCount atomic.Int64
+
+// CachedResponseCount returns the number of requests served from the cache
+// after GitHub answered 304 Not Modified.
+func CachedResponseCount() int64 {
+	return cachedResponseCount.Load()
+}
+
+// lookupCachedResponse returns the cached response for reqURL, or nil if
+// caching is off or nothing usable is stored. Cache failures only cost us the
+// conditional request, so they are logged rather than returned.
+func (c *Client) lookupCachedResponse(ctx context.Context, reqURL string) *CachedResponse {
+	if c.cache == nil {
+		return nil
+	}
+
+	cached, err := c.cache.GetCachedResponse(ctx, reqURL)
+	if err != nil {
+		log.Printf("read cached response for %s failed: %v", reqURL, err)
+		return nil
+	}
+	if cached == nil || (cached.ETag == "" && cached.LastModified == "") {
+		return nil
+	}
+
+	return cached
+}
+
+func (c *Client) storeCachedResponse(ctx context.Context, reqURL string, resp *http.Response, body []byte) {
+	if c.cache == nil {
+		return
+	}
+
+	etag := resp.Header.Get("ETag")
+	lastModified := resp.Header.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		return
+	}
+
+	err := c.cache.SaveCachedResponse(ctx, reqURL, &CachedResponse{
+		ETag:         etag,
+		LastModified: lastModified,
+		Link:         resp.Header.Get("Link"),
+		Body:         body,
+	})
+	if err != nil {
+		log.Printf("save cached response for %s failed: %v", reqURL, err)
+	}
+}
+
+func setConditionalHeaders(req *http.Request, cached *CachedResponse) {
+	if cached == nil {
+		return
+	}
+	if cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+	if cached.LastModified != "" {
+		req.Header.Set("If-Modified-Since", cached.LastModified)
+	}
+}
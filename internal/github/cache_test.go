@@ -0,0 +1,67 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+type memoryCache struct {
+	mu        sync.Mutex
+	responses map[string]*CachedResponse
+}
+
+func (c *memoryCache) GetCachedResponse(_ context.Context, url string) (*CachedResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.responses[url], nil
+}
+
+func (c *memoryCache) SaveCachedResponse(_ context.Context, url string, response *CachedResponse) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.responses[url] = response
+	return nil
+}
+
+// TestGetJSON_ConditionalRequest verifies that a second request for the same
+// URL sends the stored ETag and decodes the cached body on a 304.
+func TestGetJSON_ConditionalRequest(t *testing.T) {
+	var conditionalRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			conditionalRequests++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Link", `<https://example.com/next>; rel="next"`)
+		w.Write([]byte(`{"login":"octocat"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClientWith(t, server, Config{Cache: &memoryCache{responses: map[string]*CachedResponse{}}})
+	cachedBefore := CachedResponseCount()
+	for i := 0; i < 2; i++ {
+		user := &User{}
+		resp, err := client.getJSON(context.Background(), server.URL+"/user", user)
+		if err != nil {
+			t.Fatalf("request %d failed: %v", i, err)
+		}
+		if user.Login != "octocat" {
+			t.Errorf("request %d: expected login octocat, got %q", i, user.Login)
+		}
+		if next := parseNextURL(resp.Header.Get("Link")); next != "https://example.com/next" {
+			t.Errorf("request %d: expected next link to survive caching, got %q", i, next)
+		}
+	}
+
+	if conditionalRequests != 1 {
+		t.Errorf("expected 1 conditional request, got %d", conditionalRequests)
+	}
+	if got := CachedResponseCount() - cachedBefore; got != 1 {
+		t.Errorf("expected 1 cached response, got %d", got)
+	}
+}
@@ -1,20 +1,227 @@
 package github
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"os"
+	"slices"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
 const (
-	baseURL = "https://api.github.com"
+	// DefaultBaseURL and DefaultWebURL are used for github.com when a Config
+	// leaves them empty.
+	DefaultBaseURL = "https://api.github.com"
+	DefaultWebURL  = "https://github.com"
+
 	perPage = 100
 )
 
+// requestCount is the number of API requests made by this process. Callers
+// diff it around a piece of work to see how many requests that work used.
+var requestCount atomic.Int64
+
+// RequestCount returns the number of GitHub API requests made so far.
+func RequestCount() int64 {
+	return requestCount.Load()
+}
+
+type requestCounterKey struct{}
+
+// WithRequestCounter returns a context that adds every API request made with
+// it to counter, on top of RequestCount. It lets work that runs concurrently
+// with other requests, such as fetching one of several repositories, count
+// only its own requests.
+func WithRequestCounter(ctx context.Context, counter *atomic.Int64) context.Context {
+	return context.WithValue(ctx, requestCounterKey{}, counter)
+}
+
+// Backend selects which API a client syncs pull requests with.
+type Backend string
+
+const (
+	// BackendREST fetches each pull request with several REST calls.
+	BackendREST Backend = "rest"
+	// BackendGraphQL fetches every open pull request in a repository with a
+	// few paginated GraphQL queries.
+	BackendGraphQL Backend = "graphql"
+)
+
+// ParseBackend validates a backend name. An empty name is BackendREST.
+func ParseBackend(name string) (Backend, error) {
+	switch Backend(name) {
+	case "", BackendREST:
+		return BackendREST, nil
+	case BackendGraphQL:
+		return BackendGraphQL, nil
+	default:
+		return "", fmt.Errorf("unknown backend %q, expected %q or %q", name, BackendREST, BackendGraphQL)
+	}
+}
+
+// Config describes how to reach a GitHub host, either github.com or a GitHub
+// Enterprise Server instance.
+type Config struct {
+	// BaseURL is the REST API root, such as https://ghe.example.com/api/v3.
+	BaseURL string
+	// WebURL is the root of the web interface, such as
+	// https://ghe.example.com.
+	WebURL string
+	Token  string
+	// ProxyURL routes requests through an HTTP proxy. When empty the usual
+	// HTTPS_PROXY and NO_PROXY environment variables apply.
+	ProxyURL string
+	// CAFile is a PEM bundle trusted in addition to the system roots, for
+	// instances whose certificate is signed by an internal CA.
+	CAFile string
+	// HTTPClient, when set, is used instead of building one from ProxyURL
+	// and CAFile. WrapTransport still applies.
+	HTTPClient *http.Client
+	// WrapTransport, when set, sends every request through
+	// WrapTransport(transport), where transport is what the client would
+	// have used otherwise. It is how a Recorder or Replayer is put in front
+	// of a client.
+	WrapTransport func(http.RoundTripper) http.RoundTripper
+	// Backend is the API used to sync pull requests. Empty means
+	// BackendREST.
+	Backend Backend
+	// Cache, when set, makes GET requests conditional on the validators
+	// stored for their URL. Nil turns caching off.
+	Cache ResponseCache
+	// RetryPolicy controls how transient failures are retried. The zero
+	// value means DefaultRetryPolicy.
+	RetryPolicy RetryPolicy
+}
+
+// Client makes requests to a single GitHub host with a single token. It is
+// safe for concurrent use, and each client tracks the rate limit of its own
+// host.
+type Client struct {
+	baseURL     string
+	graphQLURL  string
+	webURL      string
+	token       string
+	backend     Backend
+	httpClient  *http.Client
+	cache       ResponseCache
+	retryPolicy RetryPolicy
+	rateLimits  *rateLimitTracker
+}
+
+func NewClient(config Config) (*Client, error) {
+	if strings.TrimSpace(config.Token) == "" {
+		return nil, errors.New("auth token is required")
+	}
+
+	baseURL := strings.TrimRight(config.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	webURL := strings.TrimRight(config.WebURL, "/")
+	if webURL == "" {
+		webURL = DefaultWebURL
+	}
+
+	backend, err := ParseBackend(string(config.Backend))
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		transport, err := newTransport(config.ProxyURL, config.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		httpClient = &http.Client{Transport: transport}
+	}
+	httpClient = wrapHTTPClient(httpClient, config.WrapTransport)
+
+	retryPolicy := config.RetryPolicy
+	if retryPolicy == (RetryPolicy{}) {
+		retryPolicy = DefaultRetryPolicy
+	}
+
+	return &Client{
+		baseURL:     baseURL,
+		graphQLURL:  graphQLURL(baseURL),
+		webURL:      webURL,
+		token:       config.Token,
+		backend:     backend,
+		httpClient:  httpClient,
+		cache:       config.Cache,
+		retryPolicy: retryPolicy,
+		rateLimits:  newRateLimitTracker(),
+	}, nil
+}
+
+func newTransport(proxyURL, caFile string) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if proxyURL != "" {
+		parsed, err := url.Parse(proxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("parse proxy url: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(parsed)
+	}
+
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("read ca file: %w", err)
+		}
+
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: roots}
+	}
+
+	return transport, nil
+}
+
+// graphQLURL returns the GraphQL endpoint that goes with a REST API root.
+// GitHub Enterprise Server serves REST under /api/v3 and GraphQL under
+// /api/graphql, while github.com serves both from the API host.
+func graphQLURL(baseURL string) string {
+	if root, ok := strings.CutSuffix(baseURL, "/api/v3"); ok {
+		return root + "/api/graphql"
+	}
+	return baseURL + "/graphql"
+}
+
+// Backend returns the API the client syncs pull requests with.
+func (c *Client) Backend() Backend {
+	return c.backend
+}
+
+// WebURL returns the root of the host's web interface.
+func (c *Client) WebURL() string {
+	return c.webURL
+}
+
+// PullRequestURL builds the web URL of a pull request. Prefer the html_url
+// GitHub returns; this is for when it is not available.
+func (c *Client) PullRequestURL(repoName string, number int) string {
+	return fmt.Sprintf("%s/%s/pull/%d", c.webURL, repoName, number)
+}
+
 type Reviewer struct {
 	Login string `json:"login"`
 }
@@ -36,9 +243,18 @@ type PullRequest struct {
 	HTMLURL   string `json:"html_url"`
 	CreatedAt string `json:"created_at"`
 	UpdatedAt string `json:"updated_at"`
+	ClosedAt  string `json:"closed_at"`
+	MergedAt  string `json:"merged_at"`
 	User      struct {
 		Login string `json:"login"`
 	} `json:"user"`
+	Head struct {
+		SHA string `json:"sha"`
+	} `json:"head"`
+	Base struct {
+		SHA string `json:"sha"`
+		Ref string `json:"ref"`
+	} `json:"base"`
 	RequestedReviewers []Reviewer `json:"requested_reviewers"`
 }
 
@@ -65,12 +281,67 @@ type ReviewComment struct {
 	} `json:"user"`
 }
 
+// Review is a submitted pull request review. State is upper case, as the
+// REST API reports it.
+type Review struct {
+	ID          int64  `json:"id"`
+	State       string `json:"state"`
+	HTMLURL     string `json:"html_url"`
+	SubmittedAt string `json:"submitted_at"`
+	CommitID    string `json:"commit_id"`
+	User        struct {
+		Login string `json:"login"`
+	} `json:"user"`
+}
+
+// Commit is a commit as the commits API reports it. Author is the GitHub
+// account the commit is attributed to, which is missing when the commit's
+// email doesn't belong to one.
+type Commit struct {
+	SHA    string `json:"sha"`
+	Author *User  `json:"author"`
+	Commit struct {
+		Message   string `json:"message"`
+		Committer struct {
+			Name string `json:"name"`
+			Date string `json:"date"`
+		} `json:"committer"`
+	} `json:"commit"`
+}
+
+// Comparison is how two commits relate, from the compare API. Status is
+// "ahead" when head descends from base, "behind" when base descends from head,
+// "identical", or "diverged" when neither does. AheadBy and BehindBy count the
+// commits only head and only base have.
+type Comparison struct {
+	Status          string `json:"status"`
+	AheadBy         int    `json:"ahead_by"`
+	BehindBy        int    `json:"behind_by"`
+	MergeBaseCommit struct {
+		SHA string `json:"sha"`
+	} `json:"merge_base_commit"`
+}
+
 type PullRequestDetails struct {
 	PullRequest
 	IssueCommentCount  int             `json:"comments"`
 	ReviewCommentCount int             `json:"review_comments"`
+	CommitCount        int             `json:"commits"`
+	Merged             bool            `json:"merged"`
+	MergedBy           *User           `json:"merged_by"`
 	IssueComments      []IssueComment  `json:"-"`
 	ReviewComments     []ReviewComment `json:"-"`
+	Reviews            []Review        `json:"-"`
+	HeadCommit         *Commit         `json:"-"`
+	// ReviewDecision is only reported by the GraphQL API, and only when
+	// branch protection requires reviews.
+	ReviewDecision string `json:"-"`
+	// Mergeable is nil while GitHub is still working out whether the pull
+	// request can be merged, which it starts doing when first asked.
+	// MergeableState is GitHub's reason, such as "dirty" for conflicts or
+	// "behind" when the base branch requires pull requests to be up to date.
+	Mergeable      *bool  `json:"mergeable"`
+	MergeableState string `json:"mergeable_state"`
 }
 
 type CommitStatusContext struct {
@@ -99,43 +370,33 @@ type CheckRun struct {
 type PullRequestCIStatuses struct {
 	PullRequestNumber int                   `json:"pull_request_number"`
 	HeadSHA           string                `json:"head_sha"`
-	CombinedState     string                `json:"combined_state"`
 	Statuses          []CommitStatusContext `json:"statuses"`
 	CheckRuns         []CheckRun            `json:"check_runs"`
 }
 
-func FetchAuthenticatedUser(authToken string) (*User, error) {
-	if strings.TrimSpace(authToken) == "" {
-		return nil, errors.New("auth token is required")
-	}
-
-	httpClient := &http.Client{}
+func (c *Client) FetchAuthenticatedUser(ctx context.Context) (*User, error) {
 	user := &User{}
 
-	userURL := fmt.Sprintf("%s/user", baseURL)
-	if _, err := getJSON(httpClient, userURL, authToken, user); err != nil {
+	userURL := fmt.Sprintf("%s/user", c.baseURL)
+	if _, err := c.getJSON(ctx, userURL, user); err != nil {
 		return nil, err
 	}
 
 	return user, nil
 }
 
-func FetchOpenPullRequests(repoName, authToken string) ([]PullRequest, error) {
+func (c *Client) FetchOpenPullRequests(ctx context.Context, repoName string) ([]PullRequest, error) {
 	if strings.TrimSpace(repoName) == "" {
 		return nil, errors.New("repo name is required")
 	}
-	if strings.TrimSpace(authToken) == "" {
-		return nil, errors.New("auth token is required")
-	}
 
-	httpClient := &http.Client{}
 	var allPRs []PullRequest
 
-	nextURL := fmt.Sprintf("%s/repos/%s/pulls?state=open&per_page=%d&page=1", baseURL, repoName, perPage)
+	nextURL := fmt.Sprintf("%s/repos/%s/pulls?state=open&per_page=%d&page=1", c.baseURL, repoName, perPage)
 	for nextURL != "" {
 		var pagePRs []PullRequest
 		log.Printf("fetching open prs from: %s", nextURL)
-		resp, err := getJSON(httpClient, nextURL, authToken, &pagePRs)
+		resp, err := c.getJSON(ctx, nextURL, &pagePRs)
 		if err != nil {
 			return nil, err
 		}
@@ -147,102 +408,182 @@ func FetchOpenPullRequests(repoName, authToken string) ([]PullRequest, error) {
 	return allPRs, nil
 }
 
-func FetchPullRequestDetails(repoName string, prID int, authToken string) (*PullRequestDetails, error) {
+// FetchPullRequest fetches a single pull request without its comments,
+// reviews or head commit, which is enough to see whether and by whom it was
+// merged.
+func (c *Client) FetchPullRequest(ctx context.Context, repoName string, prID int) (*PullRequestDetails, error) {
 	if strings.TrimSpace(repoName) == "" {
 		return nil, errors.New("repo name is required")
 	}
 	if prID <= 0 {
 		return nil, errors.New("pr id must be greater than zero")
 	}
-	if strings.TrimSpace(authToken) == "" {
-		return nil, errors.New("auth token is required")
-	}
 
-	httpClient := &http.Client{}
 	prDetails := &PullRequestDetails{}
 
-	prURL := fmt.Sprintf("%s/repos/%s/pulls/%d", baseURL, repoName, prID)
-	if _, err := getJSON(httpClient, prURL, authToken, prDetails); err != nil {
+	prURL := fmt.Sprintf("%s/repos/%s/pulls/%d", c.baseURL, repoName, prID)
+	if _, err := c.getJSON(ctx, prURL, prDetails); err != nil {
 		return nil, err
 	}
 
-	issueCommentsURL := fmt.Sprintf("%s/repos/%s/issues/%d/comments?per_page=%d", baseURL, repoName, prID, perPage)
-	issueComments, err := fetchAllIssueComments(httpClient, issueCommentsURL, authToken)
+	return prDetails, nil
+}
+
+func (c *Client) FetchPullRequestDetails(ctx context.Context, repoName string, prID int) (*PullRequestDetails, error) {
+	prDetails, err := c.FetchPullRequest(ctx, repoName, prID)
+	if err != nil {
+		return nil, err
+	}
+
+	issueCommentsURL := fmt.Sprintf("%s/repos/%s/issues/%d/comments?per_page=%d", c.baseURL, repoName, prID, perPage)
+	issueComments, err := c.fetchAllIssueComments(ctx, issueCommentsURL)
 	if err != nil {
 		return nil, err
 	}
 	prDetails.IssueComments = issueComments
 
-	reviewCommentsURL := fmt.Sprintf("%s/repos/%s/pulls/%d/comments?per_page=%d", baseURL, repoName, prID, perPage)
-	reviewComments, err := fetchAllReviewComments(httpClient, reviewCommentsURL, authToken)
+	reviewCommentsURL := fmt.Sprintf("%s/repos/%s/pulls/%d/comments?per_page=%d", c.baseURL, repoName, prID, perPage)
+	reviewComments, err := c.fetchAllReviewComments(ctx, reviewCommentsURL)
 	if err != nil {
 		return nil, err
 	}
 	prDetails.ReviewComments = reviewComments
 
+	reviewsURL := fmt.Sprintf("%s/repos/%s/pulls/%d/reviews?per_page=%d", c.baseURL, repoName, prID, perPage)
+	reviews, err := c.fetchAllReviews(ctx, reviewsURL)
+	if err != nil {
+		return nil, err
+	}
+	prDetails.Reviews = reviews
+
+	if prDetails.Head.SHA != "" {
+		headCommit, err := c.FetchCommit(ctx, repoName, prDetails.Head.SHA)
+		if err != nil {
+			return nil, err
+		}
+		prDetails.HeadCommit = headCommit
+	}
+
 	return prDetails, nil
 }
 
-func FetchPullRequestCIStatuses(repoName string, prID int, authToken string) (*PullRequestCIStatuses, error) {
-	if strings.TrimSpace(repoName) == "" {
-		return nil, errors.New("repo name is required")
+func (c *Client) FetchCommit(ctx context.Context, repoName, sha string) (*Commit, error) {
+	commit := &Commit{}
+
+	commitURL := fmt.Sprintf("%s/repos/%s/commits/%s", c.baseURL, repoName, sha)
+	if _, err := c.getJSON(ctx, commitURL, commit); err != nil {
+		return nil, err
 	}
-	if prID <= 0 {
-		return nil, errors.New("pr id must be greater than zero")
+
+	return commit, nil
+}
+
+// CompareCommits compares base with head. Only the summary is read, so a
+// single page of the commit list is requested.
+func (c *Client) CompareCommits(ctx context.Context, repoName, base, head string) (*Comparison, error) {
+	comparison := &Comparison{}
+
+	compareURL := fmt.Sprintf("%s/repos/%s/compare/%s...%s?per_page=1", c.baseURL, repoName, base, head)
+	if _, err := c.getJSON(ctx, compareURL, comparison); err != nil {
+		return nil, err
 	}
-	if strings.TrimSpace(authToken) == "" {
-		return nil, errors.New("auth token is required")
+
+	return comparison, nil
+}
+
+// FetchRequiredStatusChecks returns the names of the checks that must pass
+// before a pull request into branch can be merged, from both the branch's
+// protection and the repository rulesets that apply to it. Branch protection
+// can only be read with admin access, so a 403 is treated the same as an
+// unprotected branch, and any rulesets still apply.
+func (c *Client) FetchRequiredStatusChecks(ctx context.Context, repoName, branch string) ([]string, error) {
+	var protection struct {
+		Contexts []string `json:"contexts"`
+		Checks   []struct {
+			Context string `json:"context"`
+		} `json:"checks"`
 	}
 
-	httpClient := &http.Client{}
+	protectionURL := fmt.Sprintf("%s/repos/%s/branches/%s/protection/required_status_checks", c.baseURL, repoName, url.PathEscape(branch))
+	if _, err := c.getJSON(ctx, protectionURL, &protection); err != nil && !isStatus(err, http.StatusNotFound, http.StatusForbidden) {
+		return nil, fmt.Errorf("fetch branch protection: %w", err)
+	}
 
-	var prResponse struct {
-		Number int `json:"number"`
-		Head   struct {
-			SHA string `json:"sha"`
-		} `json:"head"`
+	var rules []struct {
+		Type       string `json:"type"`
+		Parameters struct {
+			RequiredStatusChecks []struct {
+				Context string `json:"context"`
+			} `json:"required_status_checks"`
+		} `json:"parameters"`
 	}
 
-	prURL := fmt.Sprintf("%s/repos/%s/pulls/%d", baseURL, repoName, prID)
-	if _, err := getJSON(httpClient, prURL, authToken, &prResponse); err != nil {
-		return nil, err
+	rulesURL := fmt.Sprintf("%s/repos/%s/rules/branches/%s", c.baseURL, repoName, url.PathEscape(branch))
+	if _, err := c.getJSON(ctx, rulesURL, &rules); err != nil && !isStatus(err, http.StatusNotFound) {
+		return nil, fmt.Errorf("fetch branch rules: %w", err)
+	}
+
+	required := slices.Clone(protection.Contexts)
+	for _, check := range protection.Checks {
+		required = append(required, check.Context)
 	}
-	if strings.TrimSpace(prResponse.Head.SHA) == "" {
+	for _, rule := range rules {
+		if rule.Type != "required_status_checks" {
+			continue
+		}
+		for _, check := range rule.Parameters.RequiredStatusChecks {
+			required = append(required, check.Context)
+		}
+	}
+
+	slices.Sort(required)
+	return slices.Compact(required), nil
+}
+
+// FetchPullRequestCIStatuses fetches the commit statuses and check runs on a
+// pull request's head commit. headSHA is the head from the pull request the
+// caller already fetched, which saves fetching the pull request again.
+func (c *Client) FetchPullRequestCIStatuses(ctx context.Context, repoName string, prID int, headSHA string) (*PullRequestCIStatuses, error) {
+	if strings.TrimSpace(repoName) == "" {
+		return nil, errors.New("repo name is required")
+	}
+	if prID <= 0 {
+		return nil, errors.New("pr id must be greater than zero")
+	}
+	if strings.TrimSpace(headSHA) == "" {
 		return nil, errors.New("pull request head sha is missing")
 	}
 
 	var combinedStatus struct {
-		State    string                `json:"state"`
 		Statuses []CommitStatusContext `json:"statuses"`
 	}
 
-	statusURL := fmt.Sprintf("%s/repos/%s/commits/%s/status", baseURL, repoName, prResponse.Head.SHA)
-	if _, err := getJSON(httpClient, statusURL, authToken, &combinedStatus); err != nil {
+	statusURL := fmt.Sprintf("%s/repos/%s/commits/%s/status", c.baseURL, repoName, headSHA)
+	if _, err := c.getJSON(ctx, statusURL, &combinedStatus); err != nil {
 		return nil, err
 	}
 
-	checkRunsURL := fmt.Sprintf("%s/repos/%s/commits/%s/check-runs?per_page=%d&page=1", baseURL, repoName, prResponse.Head.SHA, perPage)
-	checkRuns, err := fetchAllCheckRuns(httpClient, checkRunsURL, authToken)
+	checkRunsURL := fmt.Sprintf("%s/repos/%s/commits/%s/check-runs?per_page=%d&page=1", c.baseURL, repoName, headSHA, perPage)
+	checkRuns, err := c.fetchAllCheckRuns(ctx, checkRunsURL)
 	if err != nil {
 		return nil, err
 	}
 
 	return &PullRequestCIStatuses{
-		PullRequestNumber: prResponse.Number,
-		HeadSHA:           prResponse.Head.SHA,
-		CombinedState:     combinedStatus.State,
+		PullRequestNumber: prID,
+		HeadSHA:           headSHA,
 		Statuses:          combinedStatus.Statuses,
 		CheckRuns:         checkRuns,
 	}, nil
 }
 
-func fetchAllIssueComments(httpClient *http.Client, firstURL, authToken string) ([]IssueComment, error) {
+func (c *Client) fetchAllIssueComments(ctx context.Context, firstURL string) ([]IssueComment, error) {
 	nextURL := firstURL
 	var allComments []IssueComment
 
 	for nextURL != "" {
 		var pageComments []IssueComment
-		resp, err := getJSON(httpClient, nextURL, authToken, &pageComments)
+		resp, err := c.getJSON(ctx, nextURL, &pageComments)
 		if err != nil {
 			return nil, err
 		}
@@ -254,13 +595,13 @@ func fetchAllIssueComments(httpClient *http.Client, firstURL, authToken string)
 	return allComments, nil
 }
 
-func fetchAllReviewComments(httpClient *http.Client, firstURL, authToken string) ([]ReviewComment, error) {
+func (c *Client) fetchAllReviewComments(ctx context.Context, firstURL string) ([]ReviewComment, error) {
 	nextURL := firstURL
 	var allComments []ReviewComment
 
 	for nextURL != "" {
 		var pageComments []ReviewComment
-		resp, err := getJSON(httpClient, nextURL, authToken, &pageComments)
+		resp, err := c.getJSON(ctx, nextURL, &pageComments)
 		if err != nil {
 			return nil, err
 		}
@@ -272,7 +613,25 @@ func fetchAllReviewComments(httpClient *http.Client, firstURL, authToken string)
 	return allComments, nil
 }
 
-func fetchAllCheckRuns(httpClient *http.Client, firstURL, authToken string) ([]CheckRun, error) {
+func (c *Client) fetchAllReviews(ctx context.Context, firstURL string) ([]Review, error) {
+	nextURL := firstURL
+	var allReviews []Review
+
+	for nextURL != "" {
+		var pageReviews []Review
+		resp, err := c.getJSON(ctx, nextURL, &pageReviews)
+		if err != nil {
+			return nil, err
+		}
+
+		allReviews = append(allReviews, pageReviews...)
+		nextURL = parseNextURL(resp.Header.Get("Link"))
+	}
+
+	return allReviews, nil
+}
+
+func (c *Client) fetchAllCheckRuns(ctx context.Context, firstURL string) ([]CheckRun, error) {
 	nextURL := firstURL
 	var allCheckRuns []CheckRun
 
@@ -281,7 +640,7 @@ func fetchAllCheckRuns(httpClient *http.Client, firstURL, authToken string) ([]C
 			CheckRuns []CheckRun `json:"check_runs"`
 		}
 
-		resp, err := getJSON(httpClient, nextURL, authToken, &page)
+		resp, err := c.getJSON(ctx, nextURL, &page)
 		if err != nil {
 			return nil, err
 		}
@@ -293,33 +652,122 @@ func fetchAllCheckRuns(httpClient *http.Client, firstURL, authToken string) ([]C
 	return allCheckRuns, nil
 }
 
-func getJSON(httpClient *http.Client, reqURL, authToken string, out any) (*http.Response, error) {
-	req, err := http.NewRequest(http.MethodGet, reqURL, nil)
+func (c *Client) getJSON(ctx context.Context, reqURL string, out any) (*http.Response, error) {
+	cached := c.lookupCachedResponse(ctx, reqURL)
+
+	resp, body, err := c.do(ctx, coreResource, func(ctx context.Context) (*http.Request, error) {
+		req, err := c.newRequest(ctx, http.MethodGet, reqURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		setConditionalHeaders(req, cached)
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		cachedResponseCount.Add(1)
+		resp.Header.Set("Link", cached.Link)
+		if err := json.Unmarshal(cached.Body, out); err != nil {
+			return nil, fmt.Errorf("decode cached response: %w", err)
+		}
+		return resp, nil
+	}
+
+	if err := checkStatus(resp, body); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	c.storeCachedResponse(ctx, reqURL, resp, body)
+
+	return resp, nil
+}
+
+// do sends the request built by newRequest, first waiting on the rate limit
+// for resource and then retrying if GitHub rejects it for rate limiting.
+// Transient failures of idempotent requests are retried by doWithRetry
+// underneath. A rate limited request was refused without GitHub acting on it,
+// so it is sent again whatever its method.
+func (c *Client) do(ctx context.Context, resource string, newRequest func(ctx context.Context) (*http.Request, error)) (*http.Response, []byte, error) {
+	for attempt := 0; ; attempt++ {
+		if err := c.waitForRateLimit(ctx, resource); err != nil {
+			return nil, nil, err
+		}
+
+		resp, body, err := doWithRetry(ctx, c.httpClient, c.retryPolicy, newRequest)
+		if err != nil {
+			return nil, nil, err
+		}
+		c.rateLimits.observe(resp.Header, time.Now())
+
+		if !isRateLimited(resp, body) {
+			return resp, body, nil
+		}
+
+		delay, err := rateLimitRetryDelay(resp, attempt, time.Now())
+		if err != nil {
+			return nil, nil, err
+		}
+		log.Printf("rate limited fetching %s, retrying in %s", resp.Request.URL.Redacted(), delay)
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+func (c *Client) newRequest(ctx context.Context, method, reqURL string, body []byte) (*http.Request, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, bodyReader)
 	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+		return nil, err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+authToken)
+	req.Header.Set("Authorization", "Bearer "+c.token)
 	req.Header.Set("Accept", "application/vnd.github+json")
 	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
 	req.Header.Set("User-Agent", "pr-tracker-debug-client")
-
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
-		body, _ := io.ReadAll(io.LimitReader(resp.Body, 16*1024))
-		return nil, fmt.Errorf("github API request failed: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(body)))
+	return req, nil
+}
+
+// StatusError is returned for a response with a non-2xx status. Body is the
+// start of the response body, which is where GitHub explains what went wrong.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (err *StatusError) Error() string {
+	return fmt.Sprintf("github API request failed: status=%d body=%s", err.StatusCode, err.Body)
+}
+
+// checkStatus turns a non-2xx response into a *StatusError.
+func checkStatus(resp *http.Response, body []byte) error {
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return nil
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
+	if len(body) > 16*1024 {
+		body = body[:16*1024]
 	}
+	return &StatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+}
 
-	return resp, nil
+// isStatus reports whether err is a *StatusError with one of codes.
+func isStatus(err error, codes ...int) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && slices.Contains(codes, statusErr.StatusCode)
 }
 
 func parseNextURL(linkHeader string) string {
@@ -0,0 +1,101 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestClient(t *testing.T, server *httptest.Server) *Client {
+	t.Helper()
+	return newTestClientWith(t, server, Config{})
+}
+
+// newTestClientWith creates a client for server with the rest of its settings
+// taken from config.
+func newTestClientWith(t *testing.T, server *httptest.Server, config Config) *Client {
+	t.Helper()
+	config.BaseURL = server.URL
+	config.Token = "token"
+	config.HTTPClient = server.Client()
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+	return client
+}
+
+// TestClient_EnterpriseBaseURL verifies that requests go to the configured
+// API root with the client's token, and that web URLs use the web root.
+func TestClient_EnterpriseBaseURL(t *testing.T) {
+	var gotPath, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		w.Write([]byte(`{"login":"octocat"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{
+		BaseURL:    server.URL + "/api/v3/",
+		WebURL:     "https://ghe.example.com/",
+		Token:      "ghe-token",
+		HTTPClient: server.Client(),
+	})
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+
+	if _, err := client.FetchAuthenticatedUser(context.Background()); err != nil {
+		t.Fatalf("fetch user: %v", err)
+	}
+	if gotPath != "/api/v3/user" {
+		t.Errorf("expected request to /api/v3/user, got %s", gotPath)
+	}
+	if gotAuth != "Bearer ghe-token" {
+		t.Errorf("expected the client's token, got %q", gotAuth)
+	}
+	if got := client.PullRequestURL("platform/api", 7); got != "https://ghe.example.com/platform/api/pull/7" {
+		t.Errorf("unexpected pull request url %s", got)
+	}
+}
+
+// TestNewClient_Defaults verifies that an empty config talks to github.com.
+func TestNewClient_Defaults(t *testing.T) {
+	client, err := NewClient(Config{Token: "token"})
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+	if client.baseURL != DefaultBaseURL || client.WebURL() != DefaultWebURL {
+		t.Errorf("expected github.com defaults, got %s and %s", client.baseURL, client.WebURL())
+	}
+}
+
+// TestNewClient_InvalidSettings covers configs that cannot produce a client.
+func TestNewClient_InvalidSettings(t *testing.T) {
+	notPEM := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{name: "missing token", config: Config{}},
+		{name: "bad proxy", config: Config{Token: "token", ProxyURL: "://nope"}},
+		{name: "missing ca file", config: Config{Token: "token", CAFile: filepath.Join(t.TempDir(), "missing.pem")}},
+		{name: "ca file without certificates", config: Config{Token: "token", CAFile: notPEM}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewClient(tt.config); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
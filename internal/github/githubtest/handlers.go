@@ -0,0 +1,557 @@
+package githubtest
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The payload types below carry the subset of fields GitHub returns that the
+// client reads, with GitHub's names.
+
+type userPayload struct {
+	Login string `json:"login"`
+	ID    int64  `json:"id"`
+	Type  string `json:"type"`
+}
+
+type pullRequestPayload struct {
+	Number             int           `json:"number"`
+	Title              string        `json:"title"`
+	State              string        `json:"state"`
+	Draft              bool          `json:"draft"`
+	HTMLURL            string        `json:"html_url"`
+	CreatedAt          string        `json:"created_at"`
+	UpdatedAt          string        `json:"updated_at"`
+	ClosedAt           *string       `json:"closed_at"`
+	MergedAt           *string       `json:"merged_at"`
+	Merged             bool          `json:"merged"`
+	MergedBy           *userPayload  `json:"merged_by"`
+	User               userPayload   `json:"user"`
+	Head               headPayload   `json:"head"`
+	Base               headPayload   `json:"base"`
+	RequestedReviewers []userPayload `json:"requested_reviewers"`
+	Comments           int           `json:"comments"`
+	ReviewComments     int           `json:"review_comments"`
+	Commits            int           `json:"commits"`
+}
+
+type headPayload struct {
+	SHA string `json:"sha"`
+	Ref string `json:"ref"`
+}
+
+type commentPayload struct {
+	ID        int64       `json:"id"`
+	Body      string      `json:"body"`
+	HTMLURL   string      `json:"html_url"`
+	Path      string      `json:"path,omitempty"`
+	CreatedAt string      `json:"created_at"`
+	UpdatedAt string      `json:"updated_at"`
+	User      userPayload `json:"user"`
+}
+
+type reviewPayload struct {
+	ID          int64       `json:"id"`
+	State       string      `json:"state"`
+	HTMLURL     string      `json:"html_url"`
+	SubmittedAt string      `json:"submitted_at"`
+	CommitID    string      `json:"commit_id"`
+	User        userPayload `json:"user"`
+}
+
+type commitPayload struct {
+	SHA     string       `json:"sha"`
+	HTMLURL string       `json:"html_url"`
+	Author  *userPayload `json:"author"`
+	Commit  struct {
+		Message   string         `json:"message"`
+		Committer gitUserPayload `json:"committer"`
+	} `json:"commit"`
+}
+
+type gitUserPayload struct {
+	Name string `json:"name"`
+	Date string `json:"date"`
+}
+
+type statusPayload struct {
+	Context     string `json:"context"`
+	State       string `json:"state"`
+	Description string `json:"description"`
+	TargetURL   string `json:"target_url"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+type checkRunPayload struct {
+	ID          int64   `json:"id"`
+	Name        string  `json:"name"`
+	HeadSHA     string  `json:"head_sha"`
+	Status      string  `json:"status"`
+	Conclusion  *string `json:"conclusion"`
+	HTMLURL     string  `json:"html_url"`
+	DetailsURL  string  `json:"details_url"`
+	StartedAt   *string `json:"started_at"`
+	CompletedAt *string `json:"completed_at"`
+	App         struct {
+		Name string `json:"name"`
+	} `json:"app"`
+}
+
+func (s *Server) handleUser(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, r, userPayload{Login: Login, ID: 1, Type: "User"}, "")
+}
+
+func (s *Server) handleListPulls(w http.ResponseWriter, r *http.Request) {
+	fullName := r.PathValue("owner") + "/" + r.PathValue("repo")
+	state := r.URL.Query().Get("state")
+	if state == "" {
+		state = "open"
+	}
+
+	s.mu.Lock()
+	repo, ok := s.repos[fullName]
+	var pulls []pullRequestPayload
+	if ok {
+		for _, number := range slices.Sorted(maps.Keys(repo.pulls)) {
+			pr := repo.pulls[number]
+			if state == "all" || pr.State == state {
+				pulls = append(pulls, pullRequestJSON(fullName, pr))
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	page, link := paginate(s, r, pulls)
+	s.writeJSON(w, r, nonNil(page), link)
+}
+
+func (s *Server) handleGetPull(w http.ResponseWriter, r *http.Request) {
+	fullName, pr, ok := s.lookupPull(r)
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	// Only a single pull request reports whether it can be merged.
+	state := pr.MergeableState
+	switch {
+	case state == "" && pr.Conflicts:
+		state = "dirty"
+	case state == "":
+		state = "clean"
+	}
+	var mergeable *bool
+	if state != "unknown" {
+		mergeable = new(bool)
+		*mergeable = !pr.Conflicts
+	}
+
+	s.writeJSON(w, r, struct {
+		pullRequestPayload
+		Mergeable      *bool  `json:"mergeable"`
+		MergeableState string `json:"mergeable_state"`
+	}{pullRequestJSON(fullName, &pr), mergeable, state}, "")
+}
+
+func (s *Server) handleIssueComments(w http.ResponseWriter, r *http.Request) {
+	fullName, pr, ok := s.lookupPull(r)
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	comments := make([]commentPayload, 0, len(pr.IssueComments))
+	for _, comment := range pr.IssueComments {
+		comments = append(comments, commentJSON(fmt.Sprintf("https://github.com/%s/pull/%d#issuecomment-%d", fullName, pr.Number, comment.ID), comment))
+	}
+
+	page, link := paginate(s, r, comments)
+	s.writeJSON(w, r, nonNil(page), link)
+}
+
+func (s *Server) handleReviewComments(w http.ResponseWriter, r *http.Request) {
+	fullName, pr, ok := s.lookupPull(r)
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	comments := make([]commentPayload, 0, len(pr.ReviewComments))
+	for _, comment := range pr.ReviewComments {
+		comments = append(comments, commentJSON(fmt.Sprintf("https://github.com/%s/pull/%d#discussion_r%d", fullName, pr.Number, comment.ID), comment))
+	}
+
+	page, link := paginate(s, r, comments)
+	s.writeJSON(w, r, nonNil(page), link)
+}
+
+func (s *Server) handleReviews(w http.ResponseWriter, r *http.Request) {
+	fullName, pr, ok := s.lookupPull(r)
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	reviews := make([]reviewPayload, 0, len(pr.Reviews))
+	for _, review := range pr.Reviews {
+		commitID := review.CommitID
+		if commitID == "" {
+			commitID = pr.HeadSHA
+		}
+		reviews = append(reviews, reviewPayload{
+			ID:          review.ID,
+			State:       review.State,
+			HTMLURL:     fmt.Sprintf("https://github.com/%s/pull/%d#pullrequestreview-%d", fullName, pr.Number, review.ID),
+			SubmittedAt: timestamp(review.SubmittedAt),
+			CommitID:    commitID,
+			User:        userPayload{Login: review.Author, Type: "User"},
+		})
+	}
+
+	page, link := paginate(s, r, reviews)
+	s.writeJSON(w, r, nonNil(page), link)
+}
+
+func (s *Server) handleCommit(w http.ResponseWriter, r *http.Request) {
+	fullName := r.PathValue("owner") + "/" + r.PathValue("repo")
+	sha := r.PathValue("sha")
+
+	s.mu.Lock()
+	repo, ok := s.repos[fullName]
+	var commit Commit
+	found := false
+	if ok {
+		commit, found = repo.commits[sha]
+		if !found {
+			for _, pr := range repo.pulls {
+				if pr.HeadSHA == sha {
+					commit = Commit{SHA: sha, Author: pr.Author, Message: pr.Title, CommittedAt: pr.UpdatedAt}
+					found = true
+					break
+				}
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if !found {
+		writeError(w, http.StatusUnprocessableEntity, "No commit found for SHA: "+sha)
+		return
+	}
+
+	payload := commitPayload{
+		SHA:     commit.SHA,
+		HTMLURL: fmt.Sprintf("https://github.com/%s/commit/%s", fullName, commit.SHA),
+	}
+	if commit.Author != "" {
+		payload.Author = &userPayload{Login: commit.Author, Type: "User"}
+	}
+	payload.Commit.Message = commit.Message
+	payload.Commit.Committer = gitUserPayload{Name: commit.Author, Date: timestamp(commit.CommittedAt)}
+
+	s.writeJSON(w, r, payload, "")
+}
+
+func (s *Server) handleCompare(w http.ResponseWriter, r *http.Request) {
+	fullName := r.PathValue("owner") + "/" + r.PathValue("repo")
+	basehead := r.PathValue("basehead")
+
+	s.mu.Lock()
+	var comparison Comparison
+	ok := false
+	if repo, found := s.repos[fullName]; found {
+		comparison, ok = repo.compares[basehead]
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	base, _, _ := strings.Cut(basehead, "...")
+	s.writeJSON(w, r, map[string]any{
+		"status":            comparison.Status,
+		"ahead_by":          comparison.AheadBy,
+		"behind_by":         comparison.BehindBy,
+		"total_commits":     comparison.AheadBy,
+		"merge_base_commit": map[string]string{"sha": base},
+		"commits":           []any{},
+		"files":             []any{},
+	}, "")
+}
+
+func (s *Server) handleCombinedStatus(w http.ResponseWriter, r *http.Request) {
+	fullName := r.PathValue("owner") + "/" + r.PathValue("repo")
+	sha := r.PathValue("sha")
+
+	s.mu.Lock()
+	repo, ok := s.repos[fullName]
+	var statuses []Status
+	if ok {
+		statuses = slices.Clone(repo.statuses[sha])
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	payload := make([]statusPayload, 0, len(statuses))
+	for _, status := range statuses {
+		payload = append(payload, statusPayload{
+			Context:     status.Context,
+			State:       status.State,
+			Description: status.Description,
+			TargetURL:   status.TargetURL,
+			CreatedAt:   timestamp(status.CreatedAt),
+			UpdatedAt:   timestamp(status.UpdatedAt),
+		})
+	}
+
+	s.writeJSON(w, r, map[string]any{
+		"state":       combinedState(statuses),
+		"sha":         sha,
+		"total_count": len(statuses),
+		"statuses":    payload,
+	}, "")
+}
+
+func (s *Server) handleCheckRuns(w http.ResponseWriter, r *http.Request) {
+	fullName := r.PathValue("owner") + "/" + r.PathValue("repo")
+	sha := r.PathValue("sha")
+
+	s.mu.Lock()
+	repo, ok := s.repos[fullName]
+	var checkRuns []CheckRun
+	if ok {
+		checkRuns = slices.Clone(repo.checkRuns[sha])
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	payload := make([]checkRunPayload, 0, len(checkRuns))
+	for _, checkRun := range checkRuns {
+		payload = append(payload, checkRunJSON(fullName, sha, checkRun))
+	}
+
+	page, link := paginate(s, r, payload)
+	s.writeJSON(w, r, map[string]any{
+		"total_count": len(payload),
+		"check_runs":  nonNil(page),
+	}, link)
+}
+
+// lookupPull returns a copy of the pull request named by r's path.
+func (s *Server) handleRequiredStatusChecks(w http.ResponseWriter, r *http.Request) {
+	fullName := r.PathValue("owner") + "/" + r.PathValue("repo")
+	branch := r.PathValue("branch")
+
+	s.mu.Lock()
+	var contexts []string
+	ok := false
+	if repo, found := s.repos[fullName]; found {
+		contexts, ok = repo.protections[branch]
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "Branch not protected")
+		return
+	}
+
+	checks := make([]map[string]any, 0, len(contexts))
+	for _, context := range contexts {
+		checks = append(checks, map[string]any{"context": context, "app_id": nil})
+	}
+	s.writeJSON(w, r, map[string]any{
+		"strict":   false,
+		"contexts": contexts,
+		"checks":   checks,
+	}, "")
+}
+
+func (s *Server) handleBranchRules(w http.ResponseWriter, r *http.Request) {
+	fullName := r.PathValue("owner") + "/" + r.PathValue("repo")
+	branch := r.PathValue("branch")
+
+	s.mu.Lock()
+	var contexts []string
+	if repo, ok := s.repos[fullName]; ok {
+		contexts = slices.Clone(repo.rulesets[branch])
+	}
+	s.mu.Unlock()
+
+	rules := []any{}
+	if len(contexts) > 0 {
+		checks := make([]map[string]any, 0, len(contexts))
+		for _, context := range contexts {
+			checks = append(checks, map[string]any{"context": context})
+		}
+		rules = append(rules, map[string]any{
+			"type":       "required_status_checks",
+			"parameters": map[string]any{"required_status_checks": checks},
+		})
+	}
+	s.writeJSON(w, r, rules, "")
+}
+
+func (s *Server) lookupPull(r *http.Request) (string, PullRequest, bool) {
+	fullName := r.PathValue("owner") + "/" + r.PathValue("repo")
+	number, err := strconv.Atoi(r.PathValue("number"))
+	if err != nil {
+		return "", PullRequest{}, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	repo, ok := s.repos[fullName]
+	if !ok {
+		return "", PullRequest{}, false
+	}
+	pr, ok := repo.pulls[number]
+	if !ok {
+		return "", PullRequest{}, false
+	}
+	return fullName, *pr, true
+}
+
+func pullRequestJSON(fullName string, pr *PullRequest) pullRequestPayload {
+	reviewers := make([]userPayload, 0, len(pr.RequestedReviewers))
+	for _, login := range pr.RequestedReviewers {
+		reviewers = append(reviewers, userPayload{Login: login, Type: "User"})
+	}
+
+	var closedAt time.Time
+	var mergedBy *userPayload
+	if pr.State == "closed" {
+		closedAt = pr.UpdatedAt
+		if !pr.MergedAt.IsZero() {
+			closedAt = pr.MergedAt
+			mergedBy = &userPayload{Login: pr.MergedBy, Type: "User"}
+		}
+	}
+
+	return pullRequestPayload{
+		Number:             pr.Number,
+		Title:              pr.Title,
+		State:              pr.State,
+		Draft:              pr.Draft,
+		HTMLURL:            fmt.Sprintf("https://github.com/%s/pull/%d", fullName, pr.Number),
+		CreatedAt:          timestamp(pr.CreatedAt),
+		UpdatedAt:          timestamp(pr.UpdatedAt),
+		ClosedAt:           optionalTimestamp(closedAt),
+		MergedAt:           optionalTimestamp(pr.MergedAt),
+		Merged:             !pr.MergedAt.IsZero(),
+		MergedBy:           mergedBy,
+		User:               userPayload{Login: pr.Author, Type: "User"},
+		Head:               headPayload{SHA: pr.HeadSHA, Ref: fmt.Sprintf("pr-%d", pr.Number)},
+		Base:               headPayload{SHA: pr.BaseSHA, Ref: "main"},
+		RequestedReviewers: reviewers,
+		Comments:           len(pr.IssueComments),
+		ReviewComments:     len(pr.ReviewComments),
+		Commits:            pr.CommitCount,
+	}
+}
+
+func commentJSON(htmlURL string, comment Comment) commentPayload {
+	updatedAt := comment.UpdatedAt
+	if updatedAt.IsZero() {
+		updatedAt = comment.CreatedAt
+	}
+
+	return commentPayload{
+		ID:        comment.ID,
+		Body:      comment.Body,
+		HTMLURL:   htmlURL,
+		Path:      comment.Path,
+		CreatedAt: timestamp(comment.CreatedAt),
+		UpdatedAt: timestamp(updatedAt),
+		User:      userPayload{Login: comment.Author, Type: "User"},
+	}
+}
+
+func checkRunJSON(fullName, sha string, checkRun CheckRun) checkRunPayload {
+	status := checkRun.Status
+	if status == "" {
+		status = "completed"
+	}
+
+	payload := checkRunPayload{
+		ID:          checkRun.ID,
+		Name:        checkRun.Name,
+		HeadSHA:     sha,
+		Status:      status,
+		HTMLURL:     fmt.Sprintf("https://github.com/%s/runs/%d", fullName, checkRun.ID),
+		DetailsURL:  checkRun.DetailsURL,
+		StartedAt:   optionalTimestamp(checkRun.StartedAt),
+		CompletedAt: optionalTimestamp(checkRun.CompletedAt),
+	}
+	if checkRun.Conclusion != "" {
+		payload.Conclusion = &checkRun.Conclusion
+	}
+	payload.App.Name = checkRun.App
+
+	return payload
+}
+
+// combinedState works out the state of the combined status endpoint: failure
+// if any status failed, pending if any is pending or there are none, and
+// success otherwise.
+func combinedState(statuses []Status) string {
+	if len(statuses) == 0 {
+		return "pending"
+	}
+
+	state := "success"
+	for _, status := range statuses {
+		switch status.State {
+		case "error", "failure":
+			return "failure"
+		case "pending":
+			state = "pending"
+		}
+	}
+	return state
+}
+
+func timestamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func optionalTimestamp(t time.Time) *string {
+	if t.IsZero() {
+		return nil
+	}
+	value := timestamp(t)
+	return &value
+}
+
+// nonNil makes empty pages encode as [] rather than null, as GitHub does.
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
@@ -0,0 +1,548 @@
+// Package githubtest provides an in-memory fake of the parts of the GitHub
+// REST API the tracker uses, so the client, service and sync code can be
+// tested end to end without a network connection.
+//
+// Tests seed repositories, pull requests, comments, commit statuses and check
+// runs, then point a client at the server. The server paginates with Link
+// headers like GitHub, reports a rate limit on every response and can be told
+// to fail requests.
+package githubtest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gh "git.rileymathews.com/riley/pr-tracker/internal/github"
+)
+
+const (
+	// Token is the only token the server accepts.
+	Token = "githubtest-token"
+
+	// Login is the authenticated user returned from /user.
+	Login = "octocat"
+
+	defaultPerPage   = 30
+	maxPerPage       = 100
+	defaultRateLimit = 5000
+)
+
+// PullRequest is a seeded pull request. State defaults to "open" and HeadSHA
+// to a value derived from the number. A "closed" pull request with MergedAt
+// set is reported as merged by MergedBy. MergeableState defaults to "clean",
+// or "dirty" when Conflicts is set; "unknown" reports mergeable as null, as
+// GitHub does while it is still checking.
+type PullRequest struct {
+	Number             int
+	Title              string
+	Author             string
+	State              string
+	Draft              bool
+	HeadSHA            string
+	BaseSHA            string
+	CommitCount        int
+	Conflicts          bool
+	MergeableState     string
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	MergedAt           time.Time
+	MergedBy           string
+	RequestedReviewers []string
+	IssueComments      []Comment
+	ReviewComments     []Comment
+	Reviews            []Review
+}
+
+// Comment is an issue comment or a review comment. Path is only reported for
+// review comments.
+type Comment struct {
+	ID        int64
+	Author    string
+	Body      string
+	Path      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Review is a submitted review. State uses GitHub's upper case names, such
+// as "APPROVED". CommitID defaults to the pull request's head.
+type Review struct {
+	ID          int64
+	Author      string
+	State       string
+	SubmittedAt time.Time
+	CommitID    string
+}
+
+// Commit is a commit that can be looked up by SHA. A pull request's head
+// commit doesn't need seeding: unless one is added, it is reported as
+// committed by the pull request's author when the pull request was last
+// updated.
+type Commit struct {
+	SHA         string
+	Author      string
+	Message     string
+	CommittedAt time.Time
+}
+
+// Comparison is what the compare API reports for two commits, as in
+// Comparison.Status, "ahead", "behind", "diverged" or "identical".
+type Comparison struct {
+	Status   string
+	AheadBy  int
+	BehindBy int
+}
+
+// Status is a commit status from the legacy statuses API.
+type Status struct {
+	Context     string
+	State       string
+	Description string
+	TargetURL   string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// CheckRun is a check run reported by a GitHub App. Status defaults to
+// "completed".
+type CheckRun struct {
+	ID          int64
+	Name        string
+	App         string
+	Status      string
+	Conclusion  string
+	DetailsURL  string
+	StartedAt   time.Time
+	CompletedAt time.Time
+}
+
+type repository struct {
+	pulls     map[int]*PullRequest
+	commits   map[string]Commit
+	compares  map[string]Comparison
+	statuses  map[string][]Status
+	checkRuns map[string][]CheckRun
+
+	// protections and rulesets hold the required status checks by branch.
+	protections map[string][]string
+	rulesets    map[string][]string
+}
+
+type failure struct {
+	method  string
+	pattern string
+	status  int
+	body    string
+	times   int
+}
+
+// Server is a fake GitHub API. It is safe for concurrent use, and seeding can
+// happen while a client is talking to it.
+type Server struct {
+	server *httptest.Server
+
+	mu         sync.Mutex
+	maxPerPage int
+	repos      map[string]*repository
+	failures   []*failure
+	requests   []string
+
+	rateLimit     int
+	rateRemaining int
+	rateReset     time.Time
+}
+
+// NewServer starts a fake GitHub server that is closed when the test ends.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+
+	s := &Server{
+		maxPerPage:    maxPerPage,
+		repos:         map[string]*repository{},
+		rateLimit:     defaultRateLimit,
+		rateRemaining: defaultRateLimit,
+		rateReset:     time.Now().Add(time.Hour).Truncate(time.Second),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /user", s.handleUser)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/pulls", s.handleListPulls)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/pulls/{number}", s.handleGetPull)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/pulls/{number}/comments", s.handleReviewComments)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/pulls/{number}/reviews", s.handleReviews)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/issues/{number}/comments", s.handleIssueComments)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/commits/{sha}", s.handleCommit)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/compare/{basehead}", s.handleCompare)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/commits/{sha}/status", s.handleCombinedStatus)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/commits/{sha}/check-runs", s.handleCheckRuns)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/branches/{branch}/protection/required_status_checks", s.handleRequiredStatusChecks)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/rules/branches/{branch}", s.handleBranchRules)
+
+	s.server = httptest.NewServer(s.middleware(mux))
+	t.Cleanup(s.server.Close)
+
+	return s
+}
+
+// URL is the server's API root, to use as the client's base URL.
+func (s *Server) URL() string {
+	return s.server.URL
+}
+
+// Config returns a client config that talks to the server.
+func (s *Server) Config() gh.Config {
+	return gh.Config{
+		BaseURL:    s.server.URL,
+		WebURL:     "https://github.com",
+		Token:      Token,
+		HTTPClient: s.server.Client(),
+	}
+}
+
+// Client returns a REST client for the server.
+func (s *Server) Client(t testing.TB) *gh.Client {
+	t.Helper()
+
+	client, err := gh.NewClient(s.Config())
+	if err != nil {
+		t.Fatalf("create github client: %v", err)
+	}
+	return client
+}
+
+// SetMaxPerPage caps every page at n items whatever per_page the client asks
+// for, so pagination kicks in with a handful of seeded items.
+func (s *Server) SetMaxPerPage(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.maxPerPage = n
+}
+
+// SetRateLimit sets the budget reported in the X-RateLimit headers. Every
+// request that isn't answered with 304 Not Modified uses one; once none are
+// left requests are rejected with 403 until reset passes.
+func (s *Server) SetRateLimit(limit, remaining int, reset time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rateLimit = limit
+	s.rateRemaining = remaining
+	s.rateReset = reset.Truncate(time.Second)
+}
+
+// Fail makes requests whose path matches pattern, using path.Match syntax,
+// fail with status. times limits how many requests fail; zero or less fails
+// every matching request until the test ends.
+func (s *Server) Fail(pattern string, status int, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = append(s.failures, &failure{
+		method:  http.MethodGet,
+		pattern: pattern,
+		status:  status,
+		body:    fmt.Sprintf(`{"message":"injected failure","status":"%d"}`, status),
+		times:   times,
+	})
+}
+
+// Requests returns every request the server received as "METHOD /path?query",
+// in order.
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.requests)
+}
+
+// ResetRequests clears the request log.
+func (s *Server) ResetRequests() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = nil
+}
+
+// AddPullRequest adds pr to the repository fullName, replacing any pull
+// request with the same number.
+func (s *Server) AddPullRequest(fullName string, pr PullRequest) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if pr.State == "" {
+		pr.State = "open"
+	}
+	if pr.HeadSHA == "" {
+		pr.HeadSHA = fmt.Sprintf("%040x", pr.Number)
+	}
+	if pr.BaseSHA == "" {
+		pr.BaseSHA = strings.Repeat("0", 40)
+	}
+	if pr.CommitCount == 0 {
+		pr.CommitCount = 1
+	}
+	if pr.UpdatedAt.IsZero() {
+		pr.UpdatedAt = pr.CreatedAt
+	}
+	s.repository(fullName).pulls[pr.Number] = &pr
+}
+
+// UpdatePullRequest changes a seeded pull request in place. It fails the test
+// if the pull request doesn't exist.
+func (s *Server) UpdatePullRequest(t testing.TB, fullName string, number int, update func(pr *PullRequest)) {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pr, ok := s.repository(fullName).pulls[number]
+	if !ok {
+		t.Fatalf("githubtest: no pull request %s#%d", fullName, number)
+	}
+	update(pr)
+}
+
+// AddCommit adds commit to the repository fullName, replacing any commit
+// with the same SHA.
+func (s *Server) AddCommit(fullName string, commit Commit) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.repository(fullName).commits[commit.SHA] = commit
+}
+
+// SetComparison sets what comparing base with head reports. Comparisons that
+// weren't set answer 404, as GitHub does for a commit that no longer exists.
+func (s *Server) SetComparison(fullName, base, head string, comparison Comparison) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.repository(fullName).compares[base+"..."+head] = comparison
+}
+
+// SetStatuses replaces the commit statuses for sha.
+func (s *Server) SetStatuses(fullName, sha string, statuses ...Status) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.repository(fullName).statuses[sha] = statuses
+}
+
+// SetCheckRuns replaces the check runs for sha.
+func (s *Server) SetCheckRuns(fullName, sha string, checkRuns ...CheckRun) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.repository(fullName).checkRuns[sha] = checkRuns
+}
+
+// SetBranchProtection protects branch, requiring the status checks named in
+// contexts. Branches that weren't protected answer 404, as GitHub does.
+func (s *Server) SetBranchProtection(fullName, branch string, contexts ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.repository(fullName).protections[branch] = contexts
+}
+
+// SetRulesetRequiredChecks adds a ruleset to branch that requires the status
+// checks named in contexts, replacing any set earlier.
+func (s *Server) SetRulesetRequiredChecks(fullName, branch string, contexts ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.repository(fullName).rulesets[branch] = contexts
+}
+
+// repository returns the seeded repository, creating it if needed. s.mu must
+// be held.
+func (s *Server) repository(fullName string) *repository {
+	repo, ok := s.repos[fullName]
+	if !ok {
+		repo = &repository{
+			pulls:     map[int]*PullRequest{},
+			commits:   map[string]Commit{},
+			compares:  map[string]Comparison{},
+			statuses:  map[string][]Status{},
+			checkRuns: map[string][]CheckRun{},
+
+			protections: map[string][]string{},
+			rulesets:    map[string][]string{},
+		}
+		s.repos[fullName] = repo
+	}
+	return repo
+}
+
+// middleware logs the request, checks the token, applies injected failures
+// and the rate limit, then hands off to next.
+func (s *Server) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.Method+" "+r.URL.RequestURI())
+		s.mu.Unlock()
+
+		if r.Header.Get("Authorization") != "Bearer "+Token {
+			writeError(w, http.StatusUnauthorized, "Bad credentials")
+			return
+		}
+
+		s.mu.Lock()
+		injected := s.injectedFailure(r)
+		s.mu.Unlock()
+
+		if injected != nil {
+			s.setRateLimitHeaders(w)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(injected.status)
+			w.Write([]byte(injected.body))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// injectedFailure returns the first failure matching r, using up one of its
+// times. s.mu must be held.
+func (s *Server) injectedFailure(r *http.Request) *failure {
+	for i, f := range s.failures {
+		if f.method != r.Method {
+			continue
+		}
+		if ok, _ := path.Match(f.pattern, r.URL.Path); !ok {
+			continue
+		}
+
+		if f.times > 0 {
+			f.times--
+			if f.times == 0 {
+				s.failures = slices.Delete(s.failures, i, i+1)
+			}
+		}
+		return f
+	}
+	return nil
+}
+
+// setRateLimitHeaders reports the current budget. It doesn't use any of it.
+func (s *Server) setRateLimitHeaders(w http.ResponseWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.writeRateLimitHeaders(w)
+}
+
+func (s *Server) writeRateLimitHeaders(w http.ResponseWriter) {
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(s.rateLimit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(s.rateRemaining))
+	w.Header().Set("X-RateLimit-Used", strconv.Itoa(s.rateLimit-s.rateRemaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(s.rateReset.Unix(), 10))
+	w.Header().Set("X-RateLimit-Resource", "core")
+}
+
+// writeJSON sends value with an ETag, answering 304 Not Modified when the
+// client already has it. link is sent as the Link header if set.
+func (s *Server) writeJSON(w http.ResponseWriter, r *http.Request, value any, link string) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+
+	s.mu.Lock()
+	if now := time.Now(); !now.Before(s.rateReset) {
+		s.rateRemaining = s.rateLimit
+		s.rateReset = now.Add(time.Hour).Truncate(time.Second)
+	}
+	notModified := r.Header.Get("If-None-Match") == etag
+	exhausted := !notModified && s.rateRemaining <= 0
+	if !notModified && !exhausted {
+		s.rateRemaining--
+	}
+	s.writeRateLimitHeaders(w)
+	s.mu.Unlock()
+
+	if exhausted {
+		writeError(w, http.StatusForbidden, "API rate limit exceeded for user ID 1.")
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	if link != "" {
+		w.Header().Set("Link", link)
+	}
+	if notModified {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Write(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	body, _ := json.Marshal(map[string]string{
+		"message":           message,
+		"documentation_url": "https://docs.github.com/rest",
+	})
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// paginate returns the page of items requested by r's page and per_page
+// parameters along with the Link header pointing at the other pages.
+func paginate[T any](s *Server, r *http.Request, items []T) ([]T, string) {
+	query := r.URL.Query()
+	perPage, err := strconv.Atoi(query.Get("per_page"))
+	if err != nil || perPage <= 0 {
+		perPage = defaultPerPage
+	}
+	s.mu.Lock()
+	perPage = min(perPage, s.maxPerPage)
+	s.mu.Unlock()
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	lastPage := max((len(items)+perPage-1)/perPage, 1)
+	start := min((page-1)*perPage, len(items))
+	end := min(start+perPage, len(items))
+
+	pageURL := func(n int) string {
+		u := url.URL{Scheme: "http", Host: r.Host, Path: r.URL.Path}
+		q := r.URL.Query()
+		q.Set("per_page", strconv.Itoa(perPage))
+		q.Set("page", strconv.Itoa(n))
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+
+	var links []string
+	if page < lastPage {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, pageURL(page+1)), fmt.Sprintf(`<%s>; rel="last"`, pageURL(lastPage)))
+	}
+	if page > 1 {
+		links = append(links, fmt.Sprintf(`<%s>; rel="first"`, pageURL(1)), fmt.Sprintf(`<%s>; rel="prev"`, pageURL(page-1)))
+	}
+
+	return items[start:end], strings.Join(links, ", ")
+}
@@ -0,0 +1,120 @@
+package githubtest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	gh "git.rileymathews.com/riley/pr-tracker/internal/github"
+	"git.rileymathews.com/riley/pr-tracker/internal/github/githubtest"
+)
+
+// TestServer_Pagination verifies that the client follows the Link headers
+// across pages.
+func TestServer_Pagination(t *testing.T) {
+	server := githubtest.NewServer(t)
+	server.SetMaxPerPage(2)
+	for number := range 5 {
+		server.AddPullRequest("acme/widgets", githubtest.PullRequest{Number: number + 1, Author: "alice"})
+	}
+	server.AddPullRequest("acme/widgets", githubtest.PullRequest{Number: 6, Author: "alice", State: "closed"})
+
+	prs, err := server.Client(t).FetchOpenPullRequests(context.Background(), "acme/widgets")
+	if err != nil {
+		t.Fatalf("fetch open pull requests: %v", err)
+	}
+	if len(prs) != 5 {
+		t.Fatalf("expected 5 open pull requests, got %d", len(prs))
+	}
+
+	var pageRequests int
+	for _, request := range server.Requests() {
+		if strings.HasPrefix(request, "GET /repos/acme/widgets/pulls?") {
+			pageRequests++
+		}
+	}
+	if pageRequests != 3 {
+		t.Errorf("expected 3 page requests, got %d", pageRequests)
+	}
+}
+
+// TestServer_PullRequestDetails checks that seeded comments, statuses and
+// check runs come back through the client.
+func TestServer_PullRequestDetails(t *testing.T) {
+	created := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	server := githubtest.NewServer(t)
+	server.AddPullRequest("acme/widgets", githubtest.PullRequest{
+		Number:        7,
+		Title:         "Add caching",
+		Author:        "alice",
+		HeadSHA:       "abc123",
+		CreatedAt:     created,
+		IssueComments: []githubtest.Comment{{ID: 1, Author: "bob", CreatedAt: created.Add(time.Hour)}},
+	})
+	server.SetStatuses("acme/widgets", "abc123", githubtest.Status{Context: "ci/legacy", State: "success"})
+	server.SetCheckRuns("acme/widgets", "abc123", githubtest.CheckRun{ID: 9, Name: "test", App: "GitHub Actions", Status: "in_progress"})
+
+	client := server.Client(t)
+	details, err := client.FetchPullRequestDetails(context.Background(), "acme/widgets", 7)
+	if err != nil {
+		t.Fatalf("fetch details: %v", err)
+	}
+	if details.Head.SHA != "abc123" || len(details.IssueComments) != 1 || details.IssueComments[0].User.Login != "bob" {
+		t.Errorf("unexpected details %+v", details)
+	}
+
+	ciStatuses, err := client.FetchPullRequestCIStatuses(context.Background(), "acme/widgets", 7, details.Head.SHA)
+	if err != nil {
+		t.Fatalf("fetch ci statuses: %v", err)
+	}
+	if len(ciStatuses.Statuses) != 1 || ciStatuses.Statuses[0].State != "success" || len(ciStatuses.CheckRuns) != 1 || ciStatuses.CheckRuns[0].App.Name != "GitHub Actions" {
+		t.Errorf("unexpected ci statuses %+v", ciStatuses)
+	}
+}
+
+// TestServer_RateLimit verifies that the budget is reported and that an
+// exhausted budget is rejected the way GitHub does.
+func TestServer_RateLimit(t *testing.T) {
+	server := githubtest.NewServer(t)
+	reset := time.Now().Add(time.Hour)
+	server.SetRateLimit(100, 50, reset)
+
+	client := server.Client(t)
+	if _, err := client.FetchAuthenticatedUser(context.Background()); err != nil {
+		t.Fatalf("fetch user: %v", err)
+	}
+	status := client.CurrentCoreRateLimit()
+	if status.Limit != 100 || status.Remaining != 49 || status.Reset.Unix() != reset.Unix() {
+		t.Errorf("unexpected rate limit %+v", status)
+	}
+
+	server.SetRateLimit(100, 0, reset)
+	_, err := server.Client(t).FetchAuthenticatedUser(context.Background())
+	var rateLimitErr *gh.RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("expected a rate limit error, got %v", err)
+	}
+}
+
+// TestServer_Fail verifies that injected failures are returned for matching
+// paths only, and only as many times as asked.
+func TestServer_Fail(t *testing.T) {
+	server := githubtest.NewServer(t)
+	server.AddPullRequest("acme/widgets", githubtest.PullRequest{Number: 1, Author: "alice"})
+	server.AddPullRequest("acme/widgets", githubtest.PullRequest{Number: 2, Author: "alice"})
+	server.Fail("/repos/acme/widgets/pulls/1", http.StatusNotFound, 1)
+
+	client := server.Client(t)
+	if _, err := client.FetchPullRequestDetails(context.Background(), "acme/widgets", 1); err == nil || !strings.Contains(err.Error(), "status=404") {
+		t.Fatalf("expected an injected 404, got %v", err)
+	}
+	if _, err := client.FetchPullRequestDetails(context.Background(), "acme/widgets", 2); err != nil {
+		t.Fatalf("expected other pull requests to work, got %v", err)
+	}
+	if _, err := client.FetchPullRequestDetails(context.Background(), "acme/widgets", 1); err != nil {
+		t.Fatalf("expected the failure to be used up, got %v", err)
+	}
+}
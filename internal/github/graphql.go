@@ -0,0 +1,460 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+const (
+	// graphQLResource is the rate limit bucket used by the GraphQL API.
+	graphQLResource = "graphql"
+
+	// graphQLPageSize is how many pull requests each query asks for. Every
+	// pull request pulls in its comments and checks too, so pages are kept
+	// small enough to stay well inside GitHub's node limit.
+	graphQLPageSize = 25
+)
+
+// openPullRequestsQuery fetches one page of open pull requests along with
+// everything the sync derives its state from. Nested connections only return
+// the most recent entries, which is all the sync looks at.
+const openPullRequestsQuery = `query($owner: String!, $name: String!, $first: Int!, $after: String) {
+  repository(owner: $owner, name: $name) {
+    pullRequests(states: OPEN, first: $first, after: $after, orderBy: {field: CREATED_AT, direction: ASC}) {
+      pageInfo { hasNextPage endCursor }
+      nodes {
+        number
+        title
+        state
+        isDraft
+        url
+        createdAt
+        updatedAt
+        author { login }
+        reviewDecision
+        baseRefName
+        baseRefOid
+        baseRef {
+          refUpdateRule { requiredStatusCheckContexts }
+          rules(first: 100) {
+            nodes {
+              type
+              parameters { ... on RequiredStatusChecksParameters { requiredStatusChecks { context } } }
+            }
+          }
+        }
+        mergeable
+        mergeStateStatus
+        latestReviews(first: 100) {
+          nodes { author { login } state submittedAt }
+        }
+        reviewRequests(first: 100) {
+          nodes { requestedReviewer { ... on User { login } } }
+        }
+        comments(last: 100) {
+          nodes { updatedAt author { __typename login } }
+        }
+        reviewThreads(last: 50) {
+          nodes { comments(last: 20) { nodes { updatedAt author { __typename login } } } }
+        }
+        commits(last: 1) {
+          totalCount
+          nodes {
+            commit {
+              oid
+              committedDate
+              author { user { login } }
+              statusCheckRollup {
+                contexts(first: 100) {
+                  nodes {
+                    __typename
+                    ... on CheckRun {
+                      databaseId
+                      name
+                      status
+                      conclusion
+                      detailsUrl
+                      startedAt
+                      completedAt
+                      checkSuite { app { name } }
+                    }
+                    ... on StatusContext {
+                      context
+                      state
+                      description
+                      targetUrl
+                      createdAt
+                    }
+                  }
+                }
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// PullRequestSnapshot is what the sync needs to know about one pull request,
+// in the same shape the REST calls produce so both backends map to the same
+// models.
+type PullRequestSnapshot struct {
+	Details    *PullRequestDetails
+	CIStatuses *PullRequestCIStatuses
+	// RequiredChecks names the checks the base branch requires, from its
+	// protection rule and rulesets, the same as FetchRequiredStatusChecks.
+	RequiredChecks []string
+}
+
+type graphQLPullRequest struct {
+	Number    int    `json:"number"`
+	Title     string `json:"title"`
+	State     string `json:"state"`
+	IsDraft   bool   `json:"isDraft"`
+	URL       string `json:"url"`
+	CreatedAt string `json:"createdAt"`
+	UpdatedAt string `json:"updatedAt"`
+	Author    *struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	ReviewDecision   *string `json:"reviewDecision"`
+	BaseRefName      string  `json:"baseRefName"`
+	BaseRefOID       string  `json:"baseRefOid"`
+	Mergeable        string  `json:"mergeable"`
+	MergeStateStatus string  `json:"mergeStateStatus"`
+	LatestReviews    struct {
+		Nodes []struct {
+			Author *struct {
+				Login string `json:"login"`
+			} `json:"author"`
+			State       string `json:"state"`
+			SubmittedAt string `json:"submittedAt"`
+		} `json:"nodes"`
+	} `json:"latestReviews"`
+	ReviewRequests struct {
+		Nodes []struct {
+			RequestedReviewer *struct {
+				Login string `json:"login"`
+			} `json:"requestedReviewer"`
+		} `json:"nodes"`
+	} `json:"reviewRequests"`
+	Comments      graphQLComments `json:"comments"`
+	ReviewThreads struct {
+		Nodes []struct {
+			Comments graphQLComments `json:"comments"`
+		} `json:"nodes"`
+	} `json:"reviewThreads"`
+	Commits struct {
+		TotalCount int `json:"totalCount"`
+		Nodes      []struct {
+			Commit struct {
+				OID           string `json:"oid"`
+				CommittedDate string `json:"committedDate"`
+				Author        struct {
+					User *struct {
+						Login string `json:"login"`
+					} `json:"user"`
+				} `json:"author"`
+				StatusCheckRollup *struct {
+					Contexts struct {
+						Nodes []graphQLCheckContext `json:"nodes"`
+					} `json:"contexts"`
+				} `json:"statusCheckRollup"`
+			} `json:"commit"`
+		} `json:"nodes"`
+	} `json:"commits"`
+	BaseRef *struct {
+		RefUpdateRule *struct {
+			RequiredStatusCheckContexts []string `json:"requiredStatusCheckContexts"`
+		} `json:"refUpdateRule"`
+		Rules struct {
+			Nodes []struct {
+				Type       string `json:"type"`
+				Parameters *struct {
+					RequiredStatusChecks []struct {
+						Context string `json:"context"`
+					} `json:"requiredStatusChecks"`
+				} `json:"parameters"`
+			} `json:"nodes"`
+		} `json:"rules"`
+	} `json:"baseRef"`
+}
+
+type graphQLComments struct {
+	Nodes []struct {
+		UpdatedAt string `json:"updatedAt"`
+		Author    *struct {
+			Typename string `json:"__typename"`
+			Login    string `json:"login"`
+		} `json:"author"`
+	} `json:"nodes"`
+}
+
+// login returns the login of the i'th comment's author the way REST reports
+// it: GraphQL leaves the "[bot]" suffix off the logins of GitHub Apps.
+func (comments graphQLComments) login(i int) string {
+	author := comments.Nodes[i].Author
+	if author == nil {
+		return ""
+	}
+	if author.Typename == "Bot" {
+		return author.Login + "[bot]"
+	}
+	return author.Login
+}
+
+// graphQLCheckContext is either a CheckRun or a StatusContext, told apart by
+// Typename.
+type graphQLCheckContext struct {
+	Typename string `json:"__typename"`
+
+	DatabaseID  int64  `json:"databaseId"`
+	Name        string `json:"name"`
+	Status      string `json:"status"`
+	Conclusion  string `json:"conclusion"`
+	DetailsURL  string `json:"detailsUrl"`
+	StartedAt   string `json:"startedAt"`
+	CompletedAt string `json:"completedAt"`
+	CheckSuite  *struct {
+		App *struct {
+			Name string `json:"name"`
+		} `json:"app"`
+	} `json:"checkSuite"`
+
+	Context     string `json:"context"`
+	State       string `json:"state"`
+	Description string `json:"description"`
+	TargetURL   string `json:"targetUrl"`
+	CreatedAt   string `json:"createdAt"`
+}
+
+// FetchOpenPullRequestSnapshots fetches every open pull request in repoName
+// with its comments and checks using the GraphQL API, a page of pull requests
+// per request.
+func (c *Client) FetchOpenPullRequestSnapshots(ctx context.Context, repoName string) ([]PullRequestSnapshot, error) {
+	owner, name, ok := strings.Cut(repoName, "/")
+	if !ok || owner == "" || name == "" {
+		return nil, fmt.Errorf("repo name must be owner/name, got %q", repoName)
+	}
+
+	var snapshots []PullRequestSnapshot
+	var after *string
+	for {
+		var data struct {
+			Repository *struct {
+				PullRequests struct {
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+					Nodes []graphQLPullRequest `json:"nodes"`
+				} `json:"pullRequests"`
+			} `json:"repository"`
+		}
+
+		variables := map[string]any{"owner": owner, "name": name, "first": graphQLPageSize, "after": after}
+		if err := c.graphQL(ctx, openPullRequestsQuery, variables, &data); err != nil {
+			return nil, err
+		}
+		if data.Repository == nil {
+			return nil, fmt.Errorf("repository %s not found", repoName)
+		}
+
+		for _, pr := range data.Repository.PullRequests.Nodes {
+			snapshots = append(snapshots, pr.snapshot())
+		}
+
+		pageInfo := data.Repository.PullRequests.PageInfo
+		if !pageInfo.HasNextPage {
+			return snapshots, nil
+		}
+		after = &pageInfo.EndCursor
+	}
+}
+
+// snapshot converts a GraphQL pull request into the REST shapes. GraphQL
+// enums are upper case where REST mostly uses lower case; review states are
+// upper case in both.
+func (pr graphQLPullRequest) snapshot() PullRequestSnapshot {
+	details := &PullRequestDetails{
+		PullRequest: PullRequest{
+			Number:    pr.Number,
+			Title:     pr.Title,
+			State:     strings.ToLower(pr.State),
+			Draft:     pr.IsDraft,
+			HTMLURL:   pr.URL,
+			CreatedAt: pr.CreatedAt,
+			UpdatedAt: pr.UpdatedAt,
+		},
+	}
+	if pr.Author != nil {
+		details.User.Login = pr.Author.Login
+	}
+	details.Base.SHA = pr.BaseRefOID
+	details.Base.Ref = pr.BaseRefName
+	details.CommitCount = pr.Commits.TotalCount
+	details.MergeableState = strings.ToLower(pr.MergeStateStatus)
+	if pr.Mergeable == "MERGEABLE" || pr.Mergeable == "CONFLICTING" {
+		mergeable := pr.Mergeable == "MERGEABLE"
+		details.Mergeable = &mergeable
+	}
+	if pr.ReviewDecision != nil {
+		details.ReviewDecision = *pr.ReviewDecision
+	}
+	for _, review := range pr.LatestReviews.Nodes {
+		// Reviews by deleted accounts have no author.
+		if review.Author == nil {
+			continue
+		}
+		converted := Review{State: review.State, SubmittedAt: review.SubmittedAt}
+		converted.User.Login = review.Author.Login
+		details.Reviews = append(details.Reviews, converted)
+	}
+	for _, request := range pr.ReviewRequests.Nodes {
+		// Team review requests have no login; REST lists them separately.
+		if request.RequestedReviewer != nil && request.RequestedReviewer.Login != "" {
+			details.RequestedReviewers = append(details.RequestedReviewers, Reviewer{Login: request.RequestedReviewer.Login})
+		}
+	}
+	for i, comment := range pr.Comments.Nodes {
+		converted := IssueComment{UpdatedAt: comment.UpdatedAt}
+		converted.User.Login = pr.Comments.login(i)
+		details.IssueComments = append(details.IssueComments, converted)
+	}
+	for _, thread := range pr.ReviewThreads.Nodes {
+		for i, comment := range thread.Comments.Nodes {
+			converted := ReviewComment{UpdatedAt: comment.UpdatedAt}
+			converted.User.Login = thread.Comments.login(i)
+			details.ReviewComments = append(details.ReviewComments, converted)
+		}
+	}
+	details.IssueCommentCount = len(details.IssueComments)
+	details.ReviewCommentCount = len(details.ReviewComments)
+
+	ciStatuses := &PullRequestCIStatuses{PullRequestNumber: pr.Number}
+	if len(pr.Commits.Nodes) > 0 {
+		commit := pr.Commits.Nodes[0].Commit
+		details.Head.SHA = commit.OID
+		ciStatuses.HeadSHA = commit.OID
+		details.HeadCommit = &Commit{SHA: commit.OID}
+		details.HeadCommit.Commit.Committer.Date = commit.CommittedDate
+		if commit.Author.User != nil {
+			details.HeadCommit.Author = &User{Login: commit.Author.User.Login}
+		}
+		if commit.StatusCheckRollup != nil {
+			for _, checkContext := range commit.StatusCheckRollup.Contexts.Nodes {
+				switch checkContext.Typename {
+				case "CheckRun":
+					ciStatuses.CheckRuns = append(ciStatuses.CheckRuns, checkContext.checkRun())
+				case "StatusContext":
+					ciStatuses.Statuses = append(ciStatuses.Statuses, checkContext.statusContext())
+				}
+			}
+		}
+	}
+
+	return PullRequestSnapshot{Details: details, CIStatuses: ciStatuses, RequiredChecks: pr.requiredChecks()}
+}
+
+// requiredChecks returns the names of the checks the base branch requires.
+// The base branch is missing once it has been deleted, and its protection
+// rule is missing when it has none.
+func (pr graphQLPullRequest) requiredChecks() []string {
+	if pr.BaseRef == nil {
+		return nil
+	}
+
+	var required []string
+	if pr.BaseRef.RefUpdateRule != nil {
+		required = slices.Clone(pr.BaseRef.RefUpdateRule.RequiredStatusCheckContexts)
+	}
+	for _, rule := range pr.BaseRef.Rules.Nodes {
+		if rule.Type != "REQUIRED_STATUS_CHECKS" || rule.Parameters == nil {
+			continue
+		}
+		for _, check := range rule.Parameters.RequiredStatusChecks {
+			required = append(required, check.Context)
+		}
+	}
+
+	slices.Sort(required)
+	return slices.Compact(required)
+}
+
+func (checkContext graphQLCheckContext) checkRun() CheckRun {
+	checkRun := CheckRun{
+		ID:          checkContext.DatabaseID,
+		Name:        checkContext.Name,
+		Status:      strings.ToLower(checkContext.Status),
+		Conclusion:  strings.ToLower(checkContext.Conclusion),
+		DetailsURL:  checkContext.DetailsURL,
+		StartedAt:   checkContext.StartedAt,
+		CompletedAt: checkContext.CompletedAt,
+	}
+	if checkContext.CheckSuite != nil && checkContext.CheckSuite.App != nil {
+		checkRun.App.Name = checkContext.CheckSuite.App.Name
+	}
+	return checkRun
+}
+
+func (checkContext graphQLCheckContext) statusContext() CommitStatusContext {
+	return CommitStatusContext{
+		Context:     checkContext.Context,
+		State:       strings.ToLower(checkContext.State),
+		Description: checkContext.Description,
+		TargetURL:   checkContext.TargetURL,
+		CreatedAt:   checkContext.CreatedAt,
+		UpdatedAt:   checkContext.CreatedAt,
+	}
+}
+
+// graphQL runs query and decodes its data into out. GraphQL reports most
+// problems in an errors list on a 200 response, so those are returned as
+// errors too.
+func (c *Client) graphQL(ctx context.Context, query string, variables map[string]any, out any) error {
+	payload, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		return fmt.Errorf("encode graphql request: %w", err)
+	}
+
+	resp, body, err := c.do(ctx, graphQLResource, func(ctx context.Context) (*http.Request, error) {
+		return c.newRequest(ctx, http.MethodPost, c.graphQLURL, payload)
+	})
+	if err != nil {
+		return err
+	}
+	if err := checkStatus(resp, body); err != nil {
+		return err
+	}
+
+	var envelope struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return fmt.Errorf("decode graphql response: %w", err)
+	}
+	if len(envelope.Errors) > 0 {
+		messages := make([]string, 0, len(envelope.Errors))
+		for _, graphQLErr := range envelope.Errors {
+			messages = append(messages, graphQLErr.Message)
+		}
+		return fmt.Errorf("github graphql query failed: %s", strings.Join(messages, "; "))
+	}
+	if len(envelope.Data) == 0 || string(envelope.Data) == "null" {
+		return errors.New("github graphql response has no data")
+	}
+
+	if err := json.Unmarshal(envelope.Data, out); err != nil {
+		return fmt.Errorf("decode graphql data: %w", err)
+	}
+	return nil
+}
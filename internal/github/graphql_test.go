@@ -0,0 +1,159 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const graphQLFirstPage = `{"data":{"repository":{"pullRequests":{
+  "pageInfo":{"hasNextPage":true,"endCursor":"cursor-1"},
+  "nodes":[{
+    "number":1,"title":"Add feature","state":"OPEN","isDraft":true,
+    "url":"https://ghe.example.com/platform/api/pull/1",
+    "createdAt":"2025-01-01T10:00:00Z","updatedAt":"2025-01-02T10:00:00Z",
+    "author":{"login":"alice"},
+    "reviewDecision":"CHANGES_REQUESTED",
+    "baseRef":{"refUpdateRule":{"requiredStatusCheckContexts":["build"]},"rules":{"nodes":[
+      {"type":"REQUIRED_STATUS_CHECKS","parameters":{"requiredStatusChecks":[{"context":"ci/legacy"},{"context":"build"}]}},
+      {"type":"PULL_REQUEST","parameters":{}}
+    ]}},
+    "latestReviews":{"nodes":[{"author":{"login":"carol"},"state":"CHANGES_REQUESTED","submittedAt":"2025-01-01T13:00:00Z"},{"author":null,"state":"APPROVED","submittedAt":"2025-01-01T14:00:00Z"}]},
+    "reviewRequests":{"nodes":[{"requestedReviewer":{"login":"bob"}},{"requestedReviewer":{}}]},
+    "comments":{"nodes":[{"updatedAt":"2025-01-01T11:00:00Z","author":{"__typename":"User","login":"bob"}}]},
+    "reviewThreads":{"nodes":[{"comments":{"nodes":[{"updatedAt":"2025-01-01T12:00:00Z","author":{"__typename":"Bot","login":"codecov"}}]}}]},
+    "commits":{"nodes":[{"commit":{"oid":"abc123","committedDate":"2025-01-01T10:01:00Z","author":{"user":{"login":"alice"}},"statusCheckRollup":{"contexts":{"nodes":[
+      {"__typename":"CheckRun","databaseId":7,"name":"build","status":"COMPLETED","conclusion":"FAILURE","startedAt":"2025-01-01T10:05:00Z","completedAt":"2025-01-01T10:10:00Z","checkSuite":{"app":{"name":"GitHub Actions"}}},
+      {"__typename":"StatusContext","context":"ci/legacy","state":"SUCCESS","createdAt":"2025-01-01T10:06:00Z"}
+    ]}}}}]}
+  }]
+}}}}`
+
+const graphQLSecondPage = `{"data":{"repository":{"pullRequests":{
+  "pageInfo":{"hasNextPage":false,"endCursor":"cursor-2"},
+  "nodes":[{
+    "number":2,"title":"Fix bug","state":"OPEN","isDraft":false,
+    "url":"https://ghe.example.com/platform/api/pull/2",
+    "createdAt":"2025-01-03T10:00:00Z","updatedAt":"2025-01-03T10:00:00Z",
+    "author":null,
+    "reviewDecision":null,
+    "baseRef":null,
+    "latestReviews":{"nodes":[]},
+    "reviewRequests":{"nodes":[]},
+    "comments":{"nodes":[]},
+    "reviewThreads":{"nodes":[]},
+    "commits":{"nodes":[{"commit":{"oid":"def456","statusCheckRollup":null}}]}
+  }]
+}}}}`
+
+// TestFetchOpenPullRequestSnapshots verifies pagination against the
+// enterprise GraphQL endpoint and that results come back in the REST shapes.
+func TestFetchOpenPullRequestSnapshots(t *testing.T) {
+	var cursors []any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/graphql" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+
+		var request struct {
+			Variables map[string]any `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		if request.Variables["owner"] != "platform" || request.Variables["name"] != "api" {
+			t.Errorf("unexpected variables %v", request.Variables)
+		}
+		cursors = append(cursors, request.Variables["after"])
+
+		if request.Variables["after"] == nil {
+			w.Write([]byte(graphQLFirstPage))
+			return
+		}
+		w.Write([]byte(graphQLSecondPage))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{BaseURL: server.URL + "/api/v3", Token: "token", HTTPClient: server.Client()})
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+
+	snapshots, err := client.FetchOpenPullRequestSnapshots(context.Background(), "platform/api")
+	if err != nil {
+		t.Fatalf("fetch snapshots: %v", err)
+	}
+	if len(snapshots) != 2 {
+		t.Fatalf("expected 2 snapshots, got %d", len(snapshots))
+	}
+	if len(cursors) != 2 || cursors[1] != "cursor-1" {
+		t.Errorf("expected the second page to start after cursor-1, got %v", cursors)
+	}
+
+	first := snapshots[0]
+	if first.Details.User.Login != "alice" || !first.Details.Draft || first.Details.HTMLURL != "https://ghe.example.com/platform/api/pull/1" {
+		t.Errorf("unexpected details %+v", first.Details.PullRequest)
+	}
+	if len(first.Details.RequestedReviewers) != 1 || first.Details.RequestedReviewers[0].Login != "bob" {
+		t.Errorf("expected only bob as a requested reviewer, got %v", first.Details.RequestedReviewers)
+	}
+	if first.Details.ReviewDecision != "CHANGES_REQUESTED" || len(first.Details.Reviews) != 1 || first.Details.Reviews[0].User.Login != "carol" {
+		t.Errorf("expected carol's request for changes, got %s %+v", first.Details.ReviewDecision, first.Details.Reviews)
+	}
+	if len(first.Details.IssueComments) != 1 || len(first.Details.ReviewComments) != 1 {
+		t.Errorf("expected one issue and one review comment, got %d and %d", len(first.Details.IssueComments), len(first.Details.ReviewComments))
+	} else if first.Details.IssueComments[0].User.Login != "bob" || first.Details.ReviewComments[0].User.Login != "codecov[bot]" {
+		t.Errorf("expected comments by bob and codecov[bot], got %q and %q", first.Details.IssueComments[0].User.Login, first.Details.ReviewComments[0].User.Login)
+	}
+	if head := first.Details.HeadCommit; head == nil || head.Commit.Committer.Date != "2025-01-01T10:01:00Z" || head.Author == nil || head.Author.Login != "alice" {
+		t.Errorf("unexpected head commit %+v", head)
+	}
+	if first.CIStatuses.HeadSHA != "abc123" || len(first.CIStatuses.Statuses) != 1 || first.CIStatuses.Statuses[0].State != "success" {
+		t.Errorf("unexpected ci statuses %+v", first.CIStatuses)
+	}
+	if !slices.Equal(first.RequiredChecks, []string{"build", "ci/legacy"}) {
+		t.Errorf("expected build and ci/legacy to be required, got %v", first.RequiredChecks)
+	}
+	if len(first.CIStatuses.CheckRuns) != 1 || first.CIStatuses.CheckRuns[0].Conclusion != "failure" || first.CIStatuses.CheckRuns[0].App.Name != "GitHub Actions" {
+		t.Errorf("unexpected check runs %+v", first.CIStatuses.CheckRuns)
+	}
+
+	second := snapshots[1]
+	if second.Details.User.Login != "" || len(second.CIStatuses.Statuses) != 0 {
+		t.Errorf("expected a ghost author and no statuses, got %q and %+v", second.Details.User.Login, second.CIStatuses.Statuses)
+	}
+	if len(second.RequiredChecks) != 0 {
+		t.Errorf("expected no required checks without a base branch, got %v", second.RequiredChecks)
+	}
+}
+
+// TestGraphQL_Errors verifies that errors reported in a 200 response fail
+// the query.
+func TestGraphQL_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":null,"errors":[{"message":"Could not resolve to a Repository"}]}`))
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(t, server).FetchOpenPullRequestSnapshots(context.Background(), "platform/missing")
+	if err == nil || !strings.Contains(err.Error(), "Could not resolve to a Repository") {
+		t.Fatalf("expected the graphql error to be returned, got %v", err)
+	}
+}
+
+// TestGraphQLURL covers deriving the GraphQL endpoint from a REST base URL.
+func TestGraphQLURL(t *testing.T) {
+	tests := map[string]string{
+		DefaultBaseURL:                   "https://api.github.com/graphql",
+		"https://ghe.example.com/api/v3": "https://ghe.example.com/api/graphql",
+	}
+	for baseURL, want := range tests {
+		if got := graphQLURL(baseURL); got != want {
+			t.Errorf("graphQLURL(%s) = %s, want %s", baseURL, got, want)
+		}
+	}
+}
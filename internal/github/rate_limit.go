@@ -0,0 +1,209 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// coreResource is the rate limit bucket used by the REST API.
+	coreResource = "core"
+
+	// rateLimitReserve is the number of requests left untouched at the end of
+	// each rate limit window so other tools sharing the token keep working.
+	rateLimitReserve = 25
+
+	// maxRateLimitWait is the longest a request is held back waiting for the
+	// rate limit to reset. Longer waits fail the request instead.
+	maxRateLimitWait = 15 * time.Minute
+
+	// maxRateLimitRetries bounds how many times a request rejected for rate
+	// limiting is retried.
+	maxRateLimitRetries = 3
+
+	// defaultSecondaryRateLimitWait is used when GitHub reports a secondary
+	// rate limit without saying how long to back off.
+	defaultSecondaryRateLimitWait = time.Minute
+)
+
+// RateLimitStatus is the most recent rate limit GitHub reported for a
+// resource such as "core".
+type RateLimitStatus struct {
+	Resource   string
+	Limit      int
+	Remaining  int
+	Used       int
+	Reset      time.Time
+	ObservedAt time.Time
+}
+
+func (status RateLimitStatus) Known() bool {
+	return !status.ObservedAt.IsZero()
+}
+
+// RateLimitError is returned when GitHub is rate limiting us and waiting it
+// out would take longer than maxRateLimitWait.
+type RateLimitError struct {
+	Resource  string
+	Secondary bool
+	RetryAt   time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	kind := "rate limit"
+	if e.Secondary {
+		kind = "secondary rate limit"
+	}
+
+	return fmt.Sprintf("github %s exceeded for %s, retry after %s", kind, e.Resource, e.RetryAt.Local().Format(time.TimeOnly))
+}
+
+// CurrentRateLimit returns the latest rate limit observed for resource. The
+// zero value is returned if no request has reported one yet.
+func (c *Client) CurrentRateLimit(resource string) RateLimitStatus {
+	return c.rateLimits.current(resource)
+}
+
+// CurrentCoreRateLimit returns the latest rate limit observed for the REST
+// API.
+func (c *Client) CurrentCoreRateLimit() RateLimitStatus {
+	return c.CurrentRateLimit(coreResource)
+}
+
+// rateLimitTracker remembers the rate limit headers from the most recent
+// response for each resource. The budget is shared by every request made with
+// the client's token, so it is tracked per client rather than per request.
+type rateLimitTracker struct {
+	mu       sync.Mutex
+	statuses map[string]RateLimitStatus
+}
+
+func newRateLimitTracker() *rateLimitTracker {
+	return &rateLimitTracker{statuses: map[string]RateLimitStatus{}}
+}
+
+func (t *rateLimitTracker) current(resource string) RateLimitStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.statuses[resource]
+}
+
+func (t *rateLimitTracker) observe(header http.Header, now time.Time) {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+
+	resource := header.Get("X-RateLimit-Resource")
+	if resource == "" {
+		resource = coreResource
+	}
+
+	limit, _ := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	used, _ := strconv.Atoi(header.Get("X-RateLimit-Used"))
+	status := RateLimitStatus{
+		Resource:   resource,
+		Limit:      limit,
+		Remaining:  remaining,
+		Used:       used,
+		ObservedAt: now,
+	}
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		status.Reset = time.Unix(reset, 0).UTC()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Concurrent responses can arrive out of order; within a window the
+	// remaining count only goes down.
+	if previous, ok := t.statuses[resource]; ok && previous.Reset.Equal(status.Reset) && previous.Remaining < status.Remaining {
+		return
+	}
+	t.statuses[resource] = status
+}
+
+// delayBeforeRequest works out how long to hold a request back so the budget
+// for resource lasts until the window resets. Once the budget is down to the
+// reserve it waits for the reset; when less than a tenth of the window is
+// left, requests are spread out over the rest of it.
+func (t *rateLimitTracker) delayBeforeRequest(resource string, now time.Time) (time.Duration, error) {
+	status := t.current(resource)
+	if !status.Known() || !now.Before(status.Reset) {
+		return 0, nil
+	}
+
+	untilReset := status.Reset.Sub(now)
+	if status.Remaining <= rateLimitReserve {
+		if untilReset > maxRateLimitWait {
+			return 0, &RateLimitError{Resource: resource, RetryAt: status.Reset}
+		}
+		return untilReset, nil
+	}
+
+	if status.Limit > 0 && status.Remaining < status.Limit/10 {
+		return untilReset / time.Duration(status.Remaining-rateLimitReserve), nil
+	}
+
+	return 0, nil
+}
+
+func (c *Client) waitForRateLimit(ctx context.Context, resource string) error {
+	delay, err := c.rateLimits.delayBeforeRequest(resource, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return sleepContext(ctx, delay)
+}
+
+// isRateLimited reports whether GitHub rejected the request because of the
+// primary or a secondary rate limit rather than for lack of permission.
+func isRateLimited(resp *http.Response, body []byte) bool {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusForbidden:
+		return resp.Header.Get("X-RateLimit-Remaining") == "0" ||
+			resp.Header.Get("Retry-After") != "" ||
+			bytes.Contains(bytes.ToLower(body), []byte("rate limit"))
+	default:
+		return false
+	}
+}
+
+// rateLimitRetryDelay returns how long to wait before retrying a request that
+// was rate limited, honouring Retry-After and X-RateLimit-Reset, or an error
+// if the request should not be retried.
+func rateLimitRetryDelay(resp *http.Response, attempt int, now time.Time) (time.Duration, error) {
+	resource := resp.Header.Get("X-RateLimit-Resource")
+	if resource == "" {
+		resource = coreResource
+	}
+
+	var delay time.Duration
+	secondary := true
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		secondary = false
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			delay = time.Unix(reset, 0).Sub(now) + time.Second
+		}
+	} else {
+		delay = defaultSecondaryRateLimitWait << attempt
+	}
+	delay = max(delay, 0)
+
+	if attempt >= maxRateLimitRetries || delay > maxRateLimitWait {
+		return 0, &RateLimitError{Resource: resource, Secondary: secondary, RetryAt: now.Add(delay)}
+	}
+
+	return delay, nil
+}
@@ -0,0 +1,112 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestDelayBeforeRequest covers pacing against the last observed budget.
+func TestDelayBeforeRequest(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		remaining int
+		reset     time.Time
+		wantDelay time.Duration
+		wantErr   bool
+	}{
+		{name: "plenty left", remaining: 4000, reset: now.Add(30 * time.Minute)},
+		{name: "window already reset", remaining: 0, reset: now.Add(-time.Second)},
+		{name: "paced near the end", remaining: 125, reset: now.Add(100 * time.Second), wantDelay: time.Second},
+		{name: "reserve reached", remaining: rateLimitReserve, reset: now.Add(5 * time.Minute), wantDelay: 5 * time.Minute},
+		{name: "reset too far away", remaining: 0, reset: now.Add(time.Hour), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := &rateLimitTracker{statuses: map[string]RateLimitStatus{
+				coreResource: {Resource: coreResource, Limit: 5000, Remaining: tt.remaining, Reset: tt.reset, ObservedAt: now},
+			}}
+
+			delay, err := tracker.delayBeforeRequest(coreResource, now)
+			if tt.wantErr {
+				var rateLimitErr *RateLimitError
+				if !errors.As(err, &rateLimitErr) {
+					t.Fatalf("expected RateLimitError, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if delay != tt.wantDelay {
+				t.Errorf("expected delay %s, got %s", tt.wantDelay, delay)
+			}
+		})
+	}
+}
+
+// TestGetJSON_SecondaryRateLimitRetry verifies that a 403 carrying
+// Retry-After is retried rather than treated as a permission error, and that
+// the rate limit headers are recorded.
+func TestGetJSON_SecondaryRateLimitRetry(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Unix()
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(5000-attempts))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+		if attempts == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message":"You have exceeded a secondary rate limit."}`))
+			return
+		}
+		w.Write([]byte(`{"login":"octocat"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	user := &User{}
+	if _, err := client.getJSON(context.Background(), server.URL+"/user", user); err != nil {
+		t.Fatalf("expected request to succeed after retry: %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", attempts)
+	}
+
+	status := client.CurrentCoreRateLimit()
+	if status.Remaining != 4998 || status.Limit != 5000 {
+		t.Errorf("expected 4998 of 5000 remaining, got %d of %d", status.Remaining, status.Limit)
+	}
+}
+
+// TestGetJSON_PrimaryRateLimitTooLong verifies that exhausting the primary
+// limit with a reset far away fails fast instead of blocking the sync.
+func TestGetJSON_PrimaryRateLimitTooLong(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Resource", "search")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message":"API rate limit exceeded"}`))
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(t, server).getJSON(context.Background(), server.URL+"/search", &User{})
+	var rateLimitErr *RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("expected RateLimitError, got %v", err)
+	}
+	if rateLimitErr.Secondary {
+		t.Error("expected a primary rate limit error")
+	}
+}
@@ -0,0 +1,287 @@
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// redacted replaces credentials in recorded interactions.
+const redacted = "REDACTED"
+
+// tokenPattern matches the GitHub token formats, so a token is scrubbed from
+// recordings even if it shows up somewhere other than the request that
+// carried it.
+var tokenPattern = regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,})\b`)
+
+// droppedRequestHeaders lists the request headers that are dropped from
+// recordings: credentials, and the conditional headers, since a cassette has
+// to stand on its own without the response cache that produced them.
+var droppedRequestHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization", "If-None-Match", "If-Modified-Since"}
+
+// ErrNotRecorded is returned by a Replayer for a request that isn't in its
+// cassette. It is never retried.
+var ErrNotRecorded = errors.New("no recorded response")
+
+// Cassette is a recorded session of requests to GitHub and the responses they
+// got, stored as JSON so it can be checked into testdata and read in review.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// LoadCassette reads a cassette written by Cassette.Save.
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read cassette: %w", err)
+	}
+
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("decode cassette %s: %w", path, err)
+	}
+	return &cassette, nil
+}
+
+func (cassette *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode cassette: %w", err)
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("write cassette: %w", err)
+	}
+	return nil
+}
+
+// wrapHTTPClient applies wrap, if set, to httpClient's transport.
+func wrapHTTPClient(httpClient *http.Client, wrap func(http.RoundTripper) http.RoundTripper) *http.Client {
+	if wrap == nil {
+		return httpClient
+	}
+
+	transport := httpClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	wrapped := *httpClient
+	wrapped.Transport = wrap(transport)
+	return &wrapped
+}
+
+// Recorder captures every request made through its transports, with
+// credentials stripped, so a session can be saved as a cassette and replayed
+// later without network access.
+type Recorder struct {
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Transport returns a transport that sends requests with next and records
+// them. It can be used as Config.WrapTransport.
+func (recorder *Recorder) Transport(next http.RoundTripper) http.RoundTripper {
+	return recordingTransport{recorder: recorder, next: next}
+}
+
+// Cassette returns everything recorded so far, in the order responses
+// arrived.
+func (recorder *Recorder) Cassette() *Cassette {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	return &Cassette{Interactions: append([]Interaction(nil), recorder.interactions...)}
+}
+
+type recordingTransport struct {
+	recorder *Recorder
+	next     http.RoundTripper
+}
+
+func (transport recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var secrets []string
+	if token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer "); ok && token != "" {
+		secrets = append(secrets, token)
+	}
+
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("record request body: %w", err)
+	}
+
+	// The conditional headers are dropped from what is sent as well as from
+	// the recording, so every response recorded has a body to replay.
+	req = req.Clone(req.Context())
+	req.Header.Del("If-None-Match")
+	req.Header.Del("If-Modified-Since")
+
+	resp, err := transport.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("record response body: %w", err)
+	}
+
+	requestHeader := req.Header.Clone()
+	for _, name := range droppedRequestHeaders {
+		requestHeader.Del(name)
+	}
+	responseHeader := resp.Header.Clone()
+	responseHeader.Del("Set-Cookie")
+
+	interaction := Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    sanitize(req.URL.String(), secrets),
+			Header: sanitizeHeader(requestHeader, secrets),
+			Body:   sanitize(string(reqBody), secrets),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     sanitizeHeader(responseHeader, secrets),
+			Body:       sanitize(string(respBody), secrets),
+		},
+	}
+
+	transport.recorder.mu.Lock()
+	transport.recorder.interactions = append(transport.recorder.interactions, interaction)
+	transport.recorder.mu.Unlock()
+
+	return resp, nil
+}
+
+// readBody reads *body in full and replaces it with a reader over the same
+// bytes so the request or response can still be used.
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+
+	data, err := io.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, err
+	}
+	*body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+func sanitize(value string, secrets []string) string {
+	for _, secret := range secrets {
+		value = strings.ReplaceAll(value, secret, redacted)
+	}
+	return tokenPattern.ReplaceAllString(value, redacted)
+}
+
+func sanitizeHeader(header http.Header, secrets []string) http.Header {
+	for name, values := range header {
+		for i, value := range values {
+			values[i] = sanitize(value, secrets)
+		}
+		header[name] = values
+	}
+	return header
+}
+
+// Replayer serves the responses from a cassette instead of sending requests.
+// Requests are matched on method, path, query and body, ignoring the host, so
+// a session recorded against github.com replays whatever base URL the client
+// is configured with. Repeated requests get the recorded responses in order,
+// and the last one again once those run out.
+type Replayer struct {
+	mu           sync.Mutex
+	interactions map[string][]Interaction
+	served       map[string]int
+}
+
+func NewReplayer(cassette *Cassette) (*Replayer, error) {
+	replayer := &Replayer{
+		interactions: map[string][]Interaction{},
+		served:       map[string]int{},
+	}
+
+	for _, interaction := range cassette.Interactions {
+		req, err := http.NewRequest(interaction.Request.Method, interaction.Request.URL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("parse recorded request %s %s: %w", interaction.Request.Method, interaction.Request.URL, err)
+		}
+		key := replayKey(req, interaction.Request.Body)
+		replayer.interactions[key] = append(replayer.interactions[key], interaction)
+	}
+
+	return replayer, nil
+}
+
+// Transport returns the replayer itself, which never sends anything to next.
+// It can be used as Config.WrapTransport.
+func (replayer *Replayer) Transport(next http.RoundTripper) http.RoundTripper {
+	return replayer
+}
+
+func (replayer *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(&req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read request body: %w", err)
+	}
+
+	key := replayKey(req, string(body))
+
+	replayer.mu.Lock()
+	recorded := replayer.interactions[key]
+	index := min(replayer.served[key], len(recorded)-1)
+	replayer.served[key]++
+	replayer.mu.Unlock()
+
+	if len(recorded) == 0 {
+		return nil, fmt.Errorf("%w for %s %s", ErrNotRecorded, req.Method, req.URL.Redacted())
+	}
+
+	recordedResp := recorded[index].Response
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recordedResp.StatusCode, http.StatusText(recordedResp.StatusCode)),
+		StatusCode:    recordedResp.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recordedResp.Header.Clone(),
+		Body:          io.NopCloser(strings.NewReader(recordedResp.Body)),
+		ContentLength: int64(len(recordedResp.Body)),
+		Request:       req,
+	}, nil
+}
+
+func replayKey(req *http.Request, body string) string {
+	return req.Method + " " + req.URL.Path + "?" + req.URL.Query().Encode() + "\n" + body
+}
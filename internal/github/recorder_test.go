@@ -0,0 +1,84 @@
+package github_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gh "git.rileymathews.com/riley/pr-tracker/internal/github"
+	"git.rileymathews.com/riley/pr-tracker/internal/github/githubtest"
+)
+
+// TestRecorder_ReplaysSession records a session against the fake server,
+// checks no credentials were saved, then replays it with the server gone.
+func TestRecorder_ReplaysSession(t *testing.T) {
+	ctx := context.Background()
+
+	leaked := "ghp_" + strings.Repeat("x", 36)
+	server := githubtest.NewServer(t)
+	server.AddPullRequest("acme/widgets", githubtest.PullRequest{
+		Number:        1,
+		Title:         "Add caching",
+		Author:        "alice",
+		CreatedAt:     time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC),
+		IssueComments: []githubtest.Comment{{ID: 1, Author: "bob", Body: "try " + leaked}},
+	})
+
+	recorder := gh.NewRecorder()
+	config := server.Config()
+	config.WrapTransport = recorder.Transport
+	recordingClient, err := gh.NewClient(config)
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+	recorded, err := recordingClient.FetchPullRequestDetails(ctx, "acme/widgets", 1)
+	if err != nil {
+		t.Fatalf("fetch while recording: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "session.json")
+	if err := recorder.Cassette().Save(path); err != nil {
+		t.Fatalf("save cassette: %v", err)
+	}
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read cassette: %v", err)
+	}
+	for _, secret := range []string{githubtest.Token, leaked, "Authorization"} {
+		if strings.Contains(string(saved), secret) {
+			t.Errorf("expected %q to be stripped from the recording", secret)
+		}
+	}
+
+	cassette, err := gh.LoadCassette(path)
+	if err != nil {
+		t.Fatalf("load cassette: %v", err)
+	}
+	replayer, err := gh.NewReplayer(cassette)
+	if err != nil {
+		t.Fatalf("new replayer: %v", err)
+	}
+	server.ResetRequests()
+
+	client, err := gh.NewClient(gh.Config{Token: "replay", WrapTransport: replayer.Transport})
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+	replayed, err := client.FetchPullRequestDetails(ctx, "acme/widgets", 1)
+	if err != nil {
+		t.Fatalf("fetch while replaying: %v", err)
+	}
+	if replayed.Title != recorded.Title || len(replayed.IssueComments) != 1 {
+		t.Errorf("expected the recorded pull request, got %+v", replayed)
+	}
+	if requests := server.Requests(); len(requests) != 0 {
+		t.Errorf("expected replay to stay off the network, got %v", requests)
+	}
+
+	if _, err := client.FetchPullRequestDetails(ctx, "acme/widgets", 2); err == nil || !strings.Contains(err.Error(), "no recorded response") {
+		t.Errorf("expected unrecorded requests to fail, got %v", err)
+	}
+}
@@ -0,0 +1,192 @@
+package github
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// RetryPolicy controls how requests that fail for transient reasons, such as
+// a 502 from GitHub or a dropped connection, are retried. Delays grow
+// exponentially from BaseDelay up to MaxDelay with random jitter so
+// concurrent fetches don't retry in lockstep.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	// Values below 1 are treated as 1, which disables retries.
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// AttemptTimeout bounds a single attempt, including reading the body, so
+	// a stalled connection is retried instead of holding up the sync. Zero
+	// leaves attempts bounded only by the caller's context.
+	AttemptTimeout time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    4,
+	BaseDelay:      500 * time.Millisecond,
+	MaxDelay:       10 * time.Second,
+	AttemptTimeout: 30 * time.Second,
+}
+
+// retryCount is the number of requests that were sent again after a
+// transient failure.
+var retryCount atomic.Int64
+
+// RetryCount returns the number of requests retried after a transient
+// failure so far.
+func RetryCount() int64 {
+	return retryCount.Load()
+}
+
+// backoff returns the delay before retry number attempt (starting at 0): half
+// of the capped exponential delay plus a random share of the other half.
+func (policy RetryPolicy) backoff(attempt int) time.Duration {
+	delay := policy.MaxDelay
+	if attempt < 32 {
+		delay = min(policy.BaseDelay<<attempt, policy.MaxDelay)
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
+
+// doWithRetry sends the request built by newRequest, retrying transient
+// failures according to policy. Only idempotent requests
+// are retried, since a failed response doesn't tell us whether GitHub acted on
+// the request; that leaves out POSTs, including GraphQL queries. The body is
+// read in full so the connection can be reused whatever the caller does with
+// the response.
+//
+// newRequest must build the request with the context it is given, which
+// carries the per-attempt timeout. Once ctx itself is done no further
+// attempts are made.
+func doWithRetry(ctx context.Context, httpClient *http.Client, policy RetryPolicy, newRequest func(ctx context.Context) (*http.Request, error)) (*http.Response, []byte, error) {
+	for attempt := 0; ; attempt++ {
+		attemptCtx, cancel := policy.attemptContext(ctx)
+		req, err := newRequest(attemptCtx)
+		if err != nil {
+			cancel()
+			return nil, nil, fmt.Errorf("create request: %w", err)
+		}
+
+		resp, body, err := send(httpClient, req)
+		cancel()
+
+		transient := (err != nil && isTransientError(err)) || (err == nil && isTransientStatus(resp.StatusCode))
+		if !transient || !isIdempotent(req.Method) || attempt+1 >= policy.MaxAttempts {
+			return resp, body, err
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		delay := policy.backoff(attempt)
+		reason := "error: " + fmt.Sprint(err)
+		if err == nil {
+			reason = "status " + resp.Status
+		}
+		log.Printf("retrying %s %s in %s after %s", req.Method, req.URL.Redacted(), delay.Round(time.Millisecond), reason)
+
+		retryCount.Add(1)
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+// attemptContext derives the context for a single attempt from ctx.
+func (policy RetryPolicy) attemptContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if policy.AttemptTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, policy.AttemptTimeout)
+}
+
+// sleepContext waits for d, returning early with the context's error if ctx
+// is done first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func send(httpClient *http.Client, req *http.Request) (*http.Response, []byte, error) {
+	requestCount.Add(1)
+	if counter, ok := req.Context().Value(requestCounterKey{}).(*atomic.Int64); ok {
+		counter.Add(1)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read response: %w", err)
+	}
+
+	return resp, body, nil
+}
+
+// isIdempotent reports whether sending a request with method more than once
+// has the same effect as sending it once.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func isTransientStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// isTransientError reports whether a request error is worth retrying. Network
+// failures and attempts that hit their own timeout are; cancellation,
+// certificate problems and requests missing from a replayed session won't go
+// away on their own. doWithRetry separately stops once the caller's context
+// is done.
+func isTransientError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, ErrNotRecorded) {
+		return false
+	}
+
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	if errors.As(err, &certErr) || errors.As(err, &unknownAuthorityErr) || errors.As(err, &hostnameErr) {
+		return false
+	}
+
+	return true
+}
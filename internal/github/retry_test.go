@@ -0,0 +1,144 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var fastRetries = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+
+// TestGetJSON_RetriesTransientStatus verifies that a 502 is retried and the
+// retry is counted.
+func TestGetJSON_RetriesTransientStatus(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"login":"octocat"}`))
+	}))
+	defer server.Close()
+
+	retriesBefore := RetryCount()
+	user := &User{}
+	if _, err := newTestClientWith(t, server, Config{RetryPolicy: fastRetries}).getJSON(context.Background(), server.URL+"/user", user); err != nil {
+		t.Fatalf("expected request to succeed after retry: %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", attempts)
+	}
+	if got := RetryCount() - retriesBefore; got != 1 {
+		t.Errorf("expected 1 retry to be counted, got %d", got)
+	}
+}
+
+// TestGetJSON_GivesUpAfterMaxAttempts verifies that persistent 5xx responses
+// are surfaced once the policy is exhausted.
+func TestGetJSON_GivesUpAfterMaxAttempts(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	if _, err := newTestClientWith(t, server, Config{RetryPolicy: fastRetries}).getJSON(context.Background(), server.URL+"/user", &User{}); err == nil {
+		t.Fatal("expected an error after exhausting retries")
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+// TestDoWithRetry_NotRetrySafe verifies that a POST, which is not safe to
+// send twice, is never retried.
+func TestDoWithRetry_NotRetrySafe(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	resp, _, err := doWithRetry(context.Background(), server.Client(), fastRetries, func(ctx context.Context) (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodPost, server.URL, nil)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("expected the 502 to be returned, got %d", resp.StatusCode)
+	}
+	if attempts != 1 {
+		t.Errorf("expected 1 attempt, got %d", attempts)
+	}
+}
+
+// TestGetJSON_RetriesAttemptTimeout verifies that an attempt that stalls past
+// the policy's attempt timeout is retried rather than failing the request.
+func TestGetJSON_RetriesAttemptTimeout(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, AttemptTimeout: 50 * time.Millisecond}
+
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		w.Write([]byte(`{"login":"octocat"}`))
+	}))
+	defer server.Close()
+
+	user := &User{}
+	if _, err := newTestClientWith(t, server, Config{RetryPolicy: policy}).getJSON(context.Background(), server.URL+"/user", user); err != nil {
+		t.Fatalf("expected request to succeed after the stalled attempt: %v", err)
+	}
+	if user.Login != "octocat" {
+		t.Errorf("expected login octocat, got %q", user.Login)
+	}
+}
+
+// TestGetJSON_CancelledContextStopsRetries verifies that cancelling the
+// caller's context ends the request instead of waiting out the backoff.
+func TestGetJSON_CancelledContextStopsRetries(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: time.Minute, MaxDelay: time.Minute}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		cancel()
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	_, err := newTestClientWith(t, server, Config{RetryPolicy: policy}).getJSON(ctx, server.URL+"/user", &User{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("expected 1 attempt, got %d", got)
+	}
+}
+
+// TestRetryPolicyBackoff checks that delays grow, stay capped and keep at
+// least half of the computed delay.
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	for attempt, ceiling := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		for range 20 {
+			delay := policy.backoff(attempt)
+			if delay < ceiling/2 || delay > ceiling {
+				t.Fatalf("attempt %d: delay %s outside [%s, %s]", attempt, delay, ceiling/2, ceiling)
+			}
+		}
+	}
+}
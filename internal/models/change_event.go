@@ -0,0 +1,141 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// ChangeEventKind identifies what changed about a pull request between two
+// syncs.
+type ChangeEventKind string
+
+const (
+	ChangeEventOpened          ChangeEventKind = "opened"
+	ChangeEventNewComment      ChangeEventKind = "new_comment"
+	ChangeEventNewCommits      ChangeEventKind = "new_commits"
+	ChangeEventRebased         ChangeEventKind = "rebased"
+	ChangeEventForcePushed     ChangeEventKind = "force_pushed"
+	ChangeEventCiStatusChanged ChangeEventKind = "ci_status_changed"
+	ChangeEventCheckChanged    ChangeEventKind = "check_changed"
+	ChangeEventTitleChanged    ChangeEventKind = "title_changed"
+	ChangeEventDraftChanged    ChangeEventKind = "draft_changed"
+	ChangeEventReviewerAdded   ChangeEventKind = "reviewer_added"
+	ChangeEventReviewerRemoved ChangeEventKind = "reviewer_removed"
+	ChangeEventReviewed        ChangeEventKind = "reviewed"
+	ChangeEventReviewDecision  ChangeEventKind = "review_decision_changed"
+	ChangeEventConflicts       ChangeEventKind = "conflicts"
+	ChangeEventNeedsUpdate     ChangeEventKind = "needs_update"
+	ChangeEventMerged          ChangeEventKind = "merged"
+	ChangeEventClosed          ChangeEventKind = "closed"
+)
+
+// ChangeEvent is one thing that happened to a pull request, as worked out by
+// comparing what was stored with a fresh sync.
+//
+// Actor is the login GitHub attributes the change to, or empty when that isn't
+// known. At is when GitHub says the change happened, falling back to when the
+// sync noticed it. From and To hold the old and new values for changes of a
+// value, such as a CI status, a title or the head commit; for reviewers and
+// reviews, To is the reviewer's login or the review state. Count is how many
+// commits were added, for new commits when that is known, or how far behind
+// the base branch a pull request that needs updating is. Subject names what
+// within the pull request changed, such as the check for a check event, or the
+// base branch for conflicts and updates. RecordedAt is when the sync that
+// found the event stored it, and is zero until then.
+type ChangeEvent struct {
+	Repository string
+	Number     int
+	Kind       ChangeEventKind
+	Actor      string
+	At         time.Time
+	From       string
+	To         string
+	Count      int
+	Subject    string
+	RecordedAt time.Time
+}
+
+// String describes the event in a few words, for the TUI and notifications.
+func (event ChangeEvent) String() string {
+	switch event.Kind {
+	case ChangeEventOpened:
+		return "New PR"
+	case ChangeEventNewComment:
+		return event.byActor("New Comment")
+	case ChangeEventNewCommits:
+		switch event.Count {
+		case 0:
+			return event.byActor("New Commits")
+		case 1:
+			return event.byActor("1 New Commit")
+		default:
+			return event.byActor(fmt.Sprintf("%d New Commits", event.Count))
+		}
+	case ChangeEventRebased:
+		return event.byActor("Rebased")
+	case ChangeEventForcePushed:
+		return event.byActor("Force-pushed")
+	case ChangeEventCiStatusChanged:
+		return fmt.Sprintf("CI %s → %s", event.From, event.To)
+	case ChangeEventCheckChanged:
+		switch CheckOutcome(event.To) {
+		case CheckOutcomeFailing:
+			return "Check " + event.Subject + " failed"
+		case CheckOutcomePassing:
+			return "Check " + event.Subject + " passed"
+		default:
+			return fmt.Sprintf("Check %s %s → %s", event.Subject, event.From, event.To)
+		}
+	case ChangeEventTitleChanged:
+		return fmt.Sprintf("Title changed from %q", event.From)
+	case ChangeEventDraftChanged:
+		if event.To == "true" {
+			return "Converted to draft"
+		}
+		return "Ready for review"
+	case ChangeEventReviewerAdded:
+		return "Review requested from " + event.To
+	case ChangeEventReviewerRemoved:
+		return "Review request removed for " + event.To
+	case ChangeEventReviewed:
+		switch ReviewState(event.To) {
+		case ReviewStateApproved:
+			return "Approved by " + event.Actor
+		case ReviewStateChangesRequested:
+			return "Changes requested by " + event.Actor
+		case ReviewStateDismissed:
+			return "Review by " + event.Actor + " dismissed"
+		default:
+			return "Reviewed by " + event.Actor
+		}
+	case ChangeEventReviewDecision:
+		return fmt.Sprintf("Review decision %s → %s", ReviewDecision(event.From), ReviewDecision(event.To))
+	case ChangeEventConflicts:
+		if event.Subject == "" {
+			return "Merge conflicts"
+		}
+		return "Merge conflicts with " + event.Subject
+	case ChangeEventNeedsUpdate:
+		switch {
+		case event.Subject == "":
+			return "Needs update"
+		case event.Count > 0:
+			return "Needs update, " + commitsBehind(event.Count, event.Subject)
+		default:
+			return "Needs update from " + event.Subject
+		}
+	case ChangeEventMerged:
+		return event.byActor("Merged")
+	case ChangeEventClosed:
+		return event.byActor("Closed")
+	default:
+		return string(event.Kind)
+	}
+}
+
+func (event ChangeEvent) byActor(label string) string {
+	if event.Actor == "" {
+		return label
+	}
+	return label + " by " + event.Actor
+}
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Check is one check run or commit status reported for a pull request's head
+// commit. Commit statuses have no App and are mapped onto the check run
+// fields: Status is "pending" or "completed", and Conclusion is the status's
+// state once it is completed.
+//
+// Required is set for checks the base branch requires to pass before merging.
+// A required check that hasn't reported yet is listed with Status "expected".
+type Check struct {
+	Name        string
+	App         string
+	Status      string
+	Conclusion  string
+	DetailsURL  string
+	StartedAt   time.Time
+	CompletedAt time.Time
+	Required    bool
+}
+
+// CheckOutcome is what a check's status and conclusion amount to.
+type CheckOutcome string
+
+const (
+	CheckOutcomePending CheckOutcome = "pending"
+	CheckOutcomePassing CheckOutcome = "passing"
+	CheckOutcomeFailing CheckOutcome = "failing"
+	// CheckOutcomeNeutral is a check that finished without passing or
+	// failing, such as one that was skipped.
+	CheckOutcomeNeutral CheckOutcome = "neutral"
+)
+
+func (check Check) Outcome() CheckOutcome {
+	if check.Status != "completed" {
+		return CheckOutcomePending
+	}
+
+	switch check.Conclusion {
+	case "success":
+		return CheckOutcomePassing
+	case "neutral", "skipped":
+		return CheckOutcomeNeutral
+	case "failure", "error", "timed_out", "cancelled", "startup_failure", "action_required", "stale":
+		return CheckOutcomeFailing
+	default:
+		return CheckOutcomePending
+	}
+}
+
+// CiStatusFromChecks sums checks up into one status. When any of them is
+// required, only the required ones decide whether CI passed, and failing
+// optional ones are reported separately. When none is, for instance because
+// the base branch isn't protected, every check counts.
+func CiStatusFromChecks(checks []Check) CiStatus {
+	if len(checks) == 0 {
+		return CiStatusNoChecks
+	}
+
+	anyRequired := slices.ContainsFunc(checks, func(check Check) bool { return check.Required })
+	var failing, pending, optionalFailing bool
+	neutral := true
+	for _, check := range checks {
+		outcome := check.Outcome()
+		if anyRequired && !check.Required {
+			optionalFailing = optionalFailing || outcome == CheckOutcomeFailing
+			continue
+		}
+
+		switch outcome {
+		case CheckOutcomeFailing:
+			failing = true
+		case CheckOutcomePending:
+			pending = true
+		}
+		neutral = neutral && outcome == CheckOutcomeNeutral
+	}
+
+	switch {
+	case failing:
+		return CiStatusFailure
+	case pending:
+		return CiStatusPending
+	case optionalFailing:
+		return CiStatusOptionalFailing
+	case neutral:
+		return CiStatusNeutral
+	default:
+		return CiStatusSuccess
+	}
+}
+
+// Key identifies the check across syncs: the same name can be reported by
+// more than one app.
+func (check Check) Key() string {
+	return check.App + "/" + check.Name
+}
+
+// Label is the check's name with the app that reported it, if any.
+func (check Check) Label() string {
+	if check.App == "" {
+		return check.Name
+	}
+	return check.Name + " (" + check.App + ")"
+}
+
+// ChecksWithOutcome returns the pull request's checks that have outcome.
+func (pr PullRequest) ChecksWithOutcome(outcome CheckOutcome) []Check {
+	var checks []Check
+	for _, check := range pr.Checks {
+		if check.Outcome() == outcome {
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
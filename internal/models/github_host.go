@@ -0,0 +1,51 @@
+package models
+
+import "strings"
+
+// DefaultHost is the host of repositories tracked without a host prefix.
+const DefaultHost = "github.com"
+
+// GitHubHost holds the connection settings for a GitHub host. Repositories on
+// hosts other than github.com are tracked as "host/owner/name".
+type GitHubHost struct {
+	Host   string
+	APIURL string
+	WebURL string
+	// AccessToken may be empty for github.com, in which case the
+	// authenticated user's token is used.
+	AccessToken string
+	ProxyURL    string
+	CAFile      string
+	// Backend is the API pull requests are synced with, "rest" or
+	// "graphql".
+	Backend string
+}
+
+// SplitRepositoryName splits a tracked repository name into the host it lives
+// on and its owner/name, so "ghe.example.com/platform/api" gives
+// "ghe.example.com" and "platform/api", while "platform/api" is on
+// github.com.
+func SplitRepositoryName(name string) (host, fullName string) {
+	if strings.Count(name, "/") < 2 {
+		return DefaultHost, name
+	}
+
+	host, fullName, _ = strings.Cut(name, "/")
+	return host, fullName
+}
+
+// DefaultGitHubHost returns the usual settings for host: api.github.com for
+// github.com, and the /api/v3 path GitHub Enterprise Server serves its API
+// under for anything else.
+func DefaultGitHubHost(host string) GitHubHost {
+	if host == DefaultHost {
+		return GitHubHost{Host: host, APIURL: "https://api.github.com", WebURL: "https://github.com", Backend: "rest"}
+	}
+
+	return GitHubHost{
+		Host:    host,
+		APIURL:  "https://" + host + "/api/v3",
+		WebURL:  "https://" + host,
+		Backend: "rest",
+	}
+}
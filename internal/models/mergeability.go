@@ -0,0 +1,43 @@
+package models
+
+import "fmt"
+
+// HasConflicts reports whether GitHub found merge conflicts between the pull
+// request and its base branch.
+func (pr PullRequest) HasConflicts() bool {
+	return pr.Mergeable != nil && !*pr.Mergeable
+}
+
+// NeedsUpdate reports whether the base branch requires pull requests to be up
+// to date before merging and this one is behind it.
+func (pr PullRequest) NeedsUpdate() bool {
+	return pr.MergeableState == "behind"
+}
+
+// MergeNote explains what keeps the pull request from merging cleanly into
+// its base branch, or returns an empty string when nothing known does.
+func (pr PullRequest) MergeNote() string {
+	base := pr.BaseRef
+	if base == "" {
+		base = "the base branch"
+	}
+
+	switch {
+	case pr.HasConflicts():
+		return "Has merge conflicts with " + base
+	case pr.NeedsUpdate() && pr.BehindBy > 0:
+		return "Needs update, " + commitsBehind(pr.BehindBy, base)
+	case pr.NeedsUpdate():
+		return "Needs update from " + base
+	default:
+		return ""
+	}
+}
+
+// commitsBehind says how many commits behind base something is.
+func commitsBehind(count int, base string) string {
+	if count == 1 {
+		return "1 commit behind " + base
+	}
+	return fmt.Sprintf("%d commits behind %s", count, base)
+}
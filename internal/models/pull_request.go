@@ -2,6 +2,8 @@ package models
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -11,8 +13,82 @@ const (
 	CiStatusPending CiStatus = iota
 	CiStatusSuccess
 	CiStatusFailure
+	// CiStatusNoChecks is a head commit nothing has reported a check on.
+	CiStatusNoChecks
+	// CiStatusNeutral is a head commit whose checks all finished without
+	// passing or failing, such as when every one was skipped.
+	CiStatusNeutral
+	// CiStatusOptionalFailing is a head commit whose required checks passed
+	// but which has failing checks that don't block merging.
+	CiStatusOptionalFailing
 )
 
+func (status CiStatus) String() string {
+	switch status {
+	case CiStatusSuccess:
+		return "Success"
+	case CiStatusFailure:
+		return "Failure"
+	case CiStatusNoChecks:
+		return "No checks"
+	case CiStatusNeutral:
+		return "Neutral"
+	case CiStatusOptionalFailing:
+		return "Optional checks failing"
+	default:
+		return "Pending"
+	}
+}
+
+// HeadUpdate classifies how a pull request's head commit moved between
+// syncs.
+type HeadUpdate string
+
+const (
+	HeadUpdateUnknown HeadUpdate = ""
+	// HeadUpdateFastForward means commits were added on top of the old
+	// head.
+	HeadUpdateFastForward HeadUpdate = "fast_forward"
+	// HeadUpdateRebased means the same number of commits were replayed onto
+	// a newer base.
+	HeadUpdateRebased HeadUpdate = "rebased"
+	// HeadUpdateRewritten means the old head is no longer part of the
+	// branch for any other reason, such as amended or squashed commits.
+	HeadUpdateRewritten HeadUpdate = "rewritten"
+)
+
+// PullRequestState is whether a pull request is still open. Merged and closed
+// pull requests are archived: they are kept with their timeline but no
+// longer synced or listed with the open ones.
+type PullRequestState string
+
+const (
+	PullRequestStateOpen   PullRequestState = "open"
+	PullRequestStateMerged PullRequestState = "merged"
+	PullRequestStateClosed PullRequestState = "closed"
+)
+
+// ParsePullRequestState parses a state given on the command line.
+func ParsePullRequestState(value string) (PullRequestState, error) {
+	switch state := PullRequestState(strings.ToLower(value)); state {
+	case PullRequestStateOpen, PullRequestStateMerged, PullRequestStateClosed:
+		return state, nil
+	default:
+		return "", fmt.Errorf("unknown pull request state %q, expected open, merged or closed", value)
+	}
+}
+
+func (state PullRequestState) String() string {
+	switch state {
+	case PullRequestStateMerged:
+		return "Merged"
+	case PullRequestStateClosed:
+		return "Closed"
+	default:
+		return "Open"
+	}
+}
+
 type PullRequest struct {
 	Number     int
 	Title      string
@@ -23,42 +99,149 @@ type PullRequest struct {
 	UpdatedAt  time.Time
 	CiStatus   CiStatus
 
+	// LastCommentAt is when the newest comment from someone other than the
+	// user, a bot or an ignored commenter was written or edited, and
+	// LastCommentAuthor who wrote it.
 	LastCommentAt        time.Time
+	LastCommentAuthor    string
 	LastCommitAt         time.Time
 	LastCiStatusUpdateAt time.Time
 
 	LastAcknowledgedAt *time.Time
 
 	RequestedReviewers []string
+
+	// Checks holds the check runs and commit statuses on the head commit,
+	// ordered by name.
+	Checks []Check
+
+	// Reviews holds each reviewer's current review, ordered by reviewer.
+	Reviews        []Review
+	ReviewDecision ReviewDecision
+
+	// HTMLURL is the pull request's page as reported by GitHub, which is on
+	// the enterprise host for GitHub Enterprise repositories.
+	HTMLURL string
+
+	// HeadSHA is the pull request's head commit, and HeadCommitAuthor the
+	// login it is attributed to, if any. LastCommitAt is that commit's
+	// committer date. LastFetchedAt is when the details were last fetched in
+	// full rather than carried over from an earlier sync because the open
+	// pull request listing showed no change.
+	HeadSHA          string
+	HeadCommitAuthor string
+	LastFetchedAt    time.Time
+
+	// BaseRef is the branch the pull request targets and BaseSHA the commit
+	// on it the pull request was last compared against. CommitCount is how
+	// many commits it has on top.
+	BaseRef     string
+	BaseSHA     string
+	CommitCount int
+
+	// Mergeable is whether GitHub can merge the pull request without
+	// conflicts, or nil when it hasn't worked that out yet. MergeableState
+	// is GitHub's mergeable_state, and BehindBy how many commits the base
+	// branch has that the head doesn't.
+	Mergeable      *bool
+	MergeableState string
+	BehindBy       int
+
+	// HeadUpdate says how the head moved from the stored HeadSHA, and
+	// NewCommitCount how many commits it gained, when the sync that fetched
+	// the pull request compared the two. Neither is stored.
+	HeadUpdate     HeadUpdate
+	NewCommitCount int
+
+	// State is open for pull requests still being synced. ClosedAt is when a
+	// merged or closed pull request was closed, and for merged ones MergedAt
+	// and MergedBy say when and by whom.
+	State    PullRequestState
+	ClosedAt time.Time
+	MergedAt time.Time
+	MergedBy string
+
+	// LastSyncedAt is when the pull request was last fetched successfully.
+	// SyncError is set when the most recent attempt failed, in which case
+	// the rest of the fields are as of LastSyncedAt.
+	LastSyncedAt time.Time
+	SyncError    string
 }
 
 func (pr PullRequest) DisplayString() string {
 	return fmt.Sprintf("%s %s : %s/%d", pr.Author, pr.Title, pr.Repository, pr.Number)
 }
 
-func (pr PullRequest) UpdatesSinceLastAck() string {
-	if pr.LastAcknowledgedAt != nil {
-		updates := "  "
-		if pr.LastCommentAt.After(*pr.LastAcknowledgedAt) {
-			updates += "New Comment | "
-		}
+// UpdatesSinceLastAck summarises the events from the pull request's event log
+// that were recorded after it was last acknowledged. GitHub's timestamp for a
+// change can be older than the sync that noticed it, such as a commit made
+// before the acknowledgement but pushed after it, so events are compared by
+// when they were recorded.
+func (pr PullRequest) UpdatesSinceLastAck(events []ChangeEvent) string {
+	if pr.LastAcknowledgedAt == nil {
+		return "  New PR"
+	}
 
-		if pr.LastCommitAt.After(*pr.LastAcknowledgedAt) {
-			updates += "New Commits | "
+	updates := "  "
+	for _, event := range events {
+		recordedAt := event.RecordedAt
+		if recordedAt.IsZero() {
+			recordedAt = event.At
 		}
-
-		if pr.LastCiStatusUpdateAt.After(*pr.LastAcknowledgedAt) {
-			updates += "CI Status Changed | "
+		if recordedAt.After(*pr.LastAcknowledgedAt) {
+			updates += event.String() + " | "
 		}
+	}
+	return updates
+}
+
+func (pr PullRequest) IsStale() bool {
+	return pr.SyncError != ""
+}
+
+// StalenessNote explains why a pull request's data may be out of date, or
+// returns an empty string when the last sync succeeded.
+func (pr PullRequest) StalenessNote() string {
+	if !pr.IsStale() {
+		return ""
+	}
 
-		return updates 
+	if pr.LastSyncedAt.IsZero() {
+		return fmt.Sprintf("Stale, never synced successfully: %s", pr.SyncError)
 	}
 
-	return "  New PR"
+	return fmt.Sprintf("Stale since %s: %s", pr.LastSyncedAt.Local().Format("2006-01-02 15:04"), pr.SyncError)
+}
+
+// Reference names the pull request the way 'cli prs show' takes it, as
+// owner/repo#N with the host in front for GitHub Enterprise repositories.
+func (pr PullRequest) Reference() string {
+	return fmt.Sprintf("%s#%d", pr.Repository, pr.Number)
+}
+
+// ParsePullRequestReference splits a reference such as "acme/widgets#12" or
+// "ghe.example.com/platform/api#3" into the repository and number.
+func ParsePullRequestReference(ref string) (repository string, number int, err error) {
+	repository, numberText, ok := strings.Cut(ref, "#")
+	if !ok || !strings.Contains(repository, "/") {
+		return "", 0, fmt.Errorf("pull request %q should look like owner/repo#N", ref)
+	}
+
+	number, err = strconv.Atoi(numberText)
+	if err != nil || number <= 0 {
+		return "", 0, fmt.Errorf("pull request %q has an invalid number", ref)
+	}
+
+	return repository, number, nil
 }
 
 func (pr PullRequest) Url() string {
-	return fmt.Sprintf("https://github.com/%s/pull/%d", pr.Repository, pr.Number)
+	if pr.HTMLURL != "" {
+		return pr.HTMLURL
+	}
+
+	host, fullName := SplitRepositoryName(pr.Repository)
+	return fmt.Sprintf("https://%s/%s/pull/%d", host, fullName, pr.Number)
 }
 
 type User struct {
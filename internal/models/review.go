@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// ReviewState is the state of a submitted review, using GitHub's names.
+type ReviewState string
+
+const (
+	ReviewStateApproved         ReviewState = "APPROVED"
+	ReviewStateChangesRequested ReviewState = "CHANGES_REQUESTED"
+	ReviewStateCommented        ReviewState = "COMMENTED"
+	ReviewStateDismissed        ReviewState = "DISMISSED"
+)
+
+func (state ReviewState) String() string {
+	switch state {
+	case ReviewStateApproved:
+		return "Approved"
+	case ReviewStateChangesRequested:
+		return "Changes requested"
+	case ReviewStateCommented:
+		return "Commented"
+	case ReviewStateDismissed:
+		return "Dismissed"
+	default:
+		return string(state)
+	}
+}
+
+// Review is a reviewer's current review of a pull request: their most recent
+// approval or request for changes, or their latest comment-only review if
+// they never gave either.
+type Review struct {
+	Reviewer    string
+	State       ReviewState
+	SubmittedAt time.Time
+}
+
+// ReviewDecision is where a pull request stands with its reviewers overall.
+type ReviewDecision string
+
+const (
+	ReviewDecisionNone             ReviewDecision = ""
+	ReviewDecisionApproved         ReviewDecision = "APPROVED"
+	ReviewDecisionChangesRequested ReviewDecision = "CHANGES_REQUESTED"
+	ReviewDecisionReviewRequired   ReviewDecision = "REVIEW_REQUIRED"
+)
+
+func (decision ReviewDecision) String() string {
+	switch decision {
+	case ReviewDecisionApproved:
+		return "Approved"
+	case ReviewDecisionChangesRequested:
+		return "Changes requested"
+	case ReviewDecisionReviewRequired:
+		return "Review required"
+	default:
+		return "No reviews"
+	}
+}
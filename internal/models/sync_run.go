@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+type SyncOutcome string
+
+const (
+	SyncOutcomeSuccess SyncOutcome = "success"
+	// SyncOutcomePartial means the run finished but at least one repository
+	// or pull request could not be synced.
+	SyncOutcomePartial   SyncOutcome = "partial"
+	SyncOutcomeFailed    SyncOutcome = "failed"
+	SyncOutcomeCancelled SyncOutcome = "cancelled"
+)
+
+// SyncRun is the persisted record of a single sync.
+type SyncRun struct {
+	ID           int64
+	StartedAt    time.Time
+	FinishedAt   time.Time
+	Outcome      SyncOutcome
+	NewCount     int
+	UpdatedCount int
+	RemovedCount int
+	StaleCount   int
+	APICalls     int64
+	// CachedResponses is how many of APICalls were answered with 304 Not
+	// Modified and so did not count against the rate limit.
+	CachedResponses int64
+	// Retries is how many requests were sent again after a transient
+	// failure such as a 5xx response or a dropped connection.
+	Retries int64
+	// RateLimit, RateLimitRemaining and RateLimitReset are the REST API
+	// budget as GitHub reported it at the end of the run. They are zero if
+	// no request reported a rate limit.
+	RateLimit          int
+	RateLimitRemaining int
+	RateLimitReset     time.Time
+	Error              string
+
+	Repositories []SyncRunRepository
+}
+
+// SyncRunRepository is the persisted outcome of one repository within a
+// sync run.
+type SyncRunRepository struct {
+	Repository   string
+	Outcome      SyncOutcome
+	NewCount     int
+	UpdatedCount int
+	RemovedCount int
+	StaleCount   int
+	Duration     time.Duration
+	Error        string
+}
+
+func (run SyncRun) Duration() time.Duration {
+	return run.FinishedAt.Sub(run.StartedAt)
+}
+
+// QuotaUsed is the number of requests that counted against the rate limit.
+// Conditional requests answered with 304 Not Modified are free.
+func (run SyncRun) QuotaUsed() int64 {
+	return run.APICalls - run.CachedResponses
+}
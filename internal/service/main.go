@@ -1,54 +1,660 @@
 package service
 
 import (
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"net/http"
 	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	gh "git.rileymathews.com/riley/pr-tracker/internal/github"
 	"git.rileymathews.com/riley/pr-tracker/internal/models"
 )
 
-func FetchPullRequestDetails(repoName string, prID int, authToken string) (*models.PullRequest, error) {
-	return fetchPullRequestDetails(repoName, prID, authToken)
+func FetchPullRequestDetails(ctx context.Context, client *gh.Client, repoName string, prID int) (*models.PullRequest, error) {
+	pr, _, err := fetchPullRequestDetails(ctx, client, repoName, prID, nil, nil)
+	return pr, err
 }
 
-func FetchTrackedPullRequests(repoName string, authorsToTrack []string, authToken string) ([]*models.PullRequest, error) {
-	return fetchTrackedPullRequests(repoName, authorsToTrack, authToken)
+// DefaultParallelism is the number of GitHub fetches allowed in flight at once
+// when the caller does not choose a limit.
+const DefaultParallelism = 8
+
+// RepositoryPullRequests is the result of fetching the tracked pull requests
+// for a single repository. Err is set when the repository as a whole could not
+// be fetched; pull requests that failed individually are listed in Failures
+// and are not part of PullRequests. Unchanged counts the pull requests in
+// PullRequests that were carried over from TrackedRepository.Known instead of
+// being fetched again. Closed holds the final state of known pull requests
+// that were merged or closed since they were stored. APICalls is how many
+// GitHub requests fetching the repository took.
+type RepositoryPullRequests struct {
+	Repository   string
+	PullRequests []*models.PullRequest
+	Closed       []*models.PullRequest
+	Unchanged    int
+	Failures     []PullRequestFailure
+	Err          error
+	Duration     time.Duration
+	APICalls     int64
 }
 
-func fetchTrackedPullRequests(repoName string, authorsToTrack []string, authToken string) ([]*models.PullRequest, error) {
-	prs, err := gh.FetchOpenPullRequests(repoName, authToken)
+// TrackedRepository is a repository to fetch together with the client for the
+// host it lives on. Name is the name it is tracked under, which includes the
+// host for repositories outside github.com.
+//
+// Known holds the pull requests already stored for the repository, by number.
+// A known pull request whose head commit and updated_at in the open pull
+// request listing match what was stored is reused rather than fetched again,
+// unless it was last fetched before RefetchBefore. Leaving Known empty fetches
+// everything. When a known pull request's head has moved, the old and new
+// heads are compared to tell new commits from a force-push.
+//
+// Comments by IgnoredCommenters, or by any GitHub App, don't count towards a
+// pull request's LastCommentAt.
+type TrackedRepository struct {
+	Name              string
+	Client            *gh.Client
+	Known             map[int]*models.PullRequest
+	RefetchBefore     time.Time
+	IgnoredCommenters []string
+}
+
+// PullRequestFailure records a tracked pull request that is still open but
+// whose details could not be fetched.
+type PullRequestFailure struct {
+	Number int
+	Err    error
+}
+
+func FetchTrackedPullRequests(ctx context.Context, client *gh.Client, repoName string, authorsToTrack []string, parallelism int) ([]*models.PullRequest, []PullRequestFailure, error) {
+	prs, _, failures, err := fetchTrackedPullRequests(ctx, newLimiter(parallelism), TrackedRepository{Name: repoName, Client: client}, authorsToTrack)
+	return prs, failures, err
+}
+
+// FetchTrackedRepositories fetches the tracked pull requests for every
+// repository concurrently. At most parallelism fetches are in flight at once
+// across all repositories, whichever host they are on, and results are
+// returned in the same order as repos. Once ctx is cancelled no new fetches
+// are started.
+func FetchTrackedRepositories(ctx context.Context, repos []TrackedRepository, authorsToTrack []string, parallelism int) []RepositoryPullRequests {
+	limiter := newLimiter(parallelism)
+	results := make([]RepositoryPullRequests, len(repos))
+
+	var wg sync.WaitGroup
+	for i, repo := range repos {
+		wg.Go(func() {
+			started := time.Now()
+			var apiCalls atomic.Int64
+			ctx := gh.WithRequestCounter(ctx, &apiCalls)
+			prs, unchanged, failures, err := fetchTrackedPullRequests(ctx, limiter, repo, authorsToTrack)
+			results[i] = RepositoryPullRequests{
+				Repository:   repo.Name,
+				PullRequests: prs,
+				Unchanged:    unchanged,
+				Failures:     failures,
+				Err:          err,
+			}
+			if err == nil {
+				fetchFinalStates(ctx, limiter, repo, slices.Sorted(maps.Keys(repo.Known)), &results[i])
+			}
+			results[i].Duration = time.Since(started)
+			results[i].APICalls = apiCalls.Load()
+		})
+	}
+	wg.Wait()
+
+	return results
+}
+
+// FetchPullRequests fetches the given pull requests of one repository
+// directly, without listing the repository first. It is used when something
+// else, such as a webhook delivery, says which pull requests changed. Pull
+// requests that are no longer open or not by a tracked author are left out of
+// the result, the same as they would be missing from a full listing.
+func FetchPullRequests(ctx context.Context, repo TrackedRepository, numbers []int, authorsToTrack []string, parallelism int) RepositoryPullRequests {
+	limiter := newLimiter(parallelism)
+	started := time.Now()
+	var apiCalls atomic.Int64
+	ctx = gh.WithRequestCounter(ctx, &apiCalls)
+
+	prs := make([]*models.PullRequest, len(numbers))
+	closedDetails := make([]*gh.PullRequestDetails, len(numbers))
+	errs := make([]error, len(numbers))
+
+	var wg sync.WaitGroup
+	for i, number := range numbers {
+		wg.Go(func() {
+			if err := limiter.acquire(ctx); err != nil {
+				errs[i] = err
+				return
+			}
+			defer limiter.release()
+
+			prs[i], closedDetails[i], errs[i] = fetchOpenTrackedPullRequest(ctx, repo.Client, repo.Name, number, authorsToTrack, repo.IgnoredCommenters)
+		})
+	}
+	wg.Wait()
+
+	result := RepositoryPullRequests{Repository: repo.Name}
+	if err := ctx.Err(); err != nil {
+		result.Err = err
+		return result
+	}
+
+	for i, number := range numbers {
+		switch {
+		case errs[i] != nil:
+			result.Failures = append(result.Failures, PullRequestFailure{
+				Number: number,
+				Err:    fmt.Errorf("fetch pr details for #%d: %w", number, errs[i]),
+			})
+		case prs[i] != nil:
+			result.PullRequests = append(result.PullRequests, prs[i])
+		case closedDetails[i] != nil && repo.Known[number] != nil:
+			closed, err := closedPullRequest(repo.Known[number], closedDetails[i])
+			if err != nil {
+				result.Failures = append(result.Failures, PullRequestFailure{
+					Number: number,
+					Err:    fmt.Errorf("read final state of #%d: %w", number, err),
+				})
+				continue
+			}
+			result.Closed = append(result.Closed, closed)
+		}
+	}
+	markRequiredChecks(ctx, limiter, repo, result.PullRequests)
+	compareWithBase(ctx, limiter, repo, result.PullRequests, false)
+	compareHeads(ctx, limiter, repo, result.PullRequests)
+	fetchFinalStates(ctx, limiter, repo, numbers, &result)
+	result.Duration = time.Since(started)
+	result.APICalls = apiCalls.Load()
+
+	return result
+}
+
+// fetchOpenTrackedPullRequest fetches a single pull request, returning a nil
+// pull request without an error when it is closed or its author isn't
+// tracked. A closed pull request's details are returned instead, so its final
+// state can be recorded without fetching it again.
+func fetchOpenTrackedPullRequest(ctx context.Context, client *gh.Client, repoName string, prID int, authorsToTrack, ignoredCommenters []string) (*models.PullRequest, *gh.PullRequestDetails, error) {
+	pr, prDetails, err := fetchPullRequestDetails(ctx, client, repoName, prID, ignoredCommenters, func(prDetails *gh.PullRequestDetails) bool {
+		return prDetails.State == "open" && shouldTrackPR(&prDetails.PullRequest, authorsToTrack)
+	})
+	if err != nil || pr != nil || prDetails.State == "open" {
+		return pr, nil, err
+	}
+	return nil, prDetails, nil
+}
+
+// fetchTrackedPullRequests lists the open pull requests in repo and fetches
+// the tracked ones in full, apart from those reused from repo.Known. It
+// returns how many were reused alongside the pull requests.
+func fetchTrackedPullRequests(ctx context.Context, limiter limiter, repo TrackedRepository, authorsToTrack []string) ([]*models.PullRequest, int, []PullRequestFailure, error) {
+	client, repoName := repo.Client, repo.Name
+	if client.Backend() == gh.BackendGraphQL {
+		// A GraphQL page already carries everything about its pull
+		// requests, so there is nothing to save by skipping some.
+		prs, failures, err := fetchTrackedPullRequestsGraphQL(ctx, limiter, client, repoName, authorsToTrack, repo.IgnoredCommenters)
+		if err == nil {
+			compareWithBase(ctx, limiter, repo, prs, true)
+			compareHeads(ctx, limiter, repo, prs)
+		}
+		return prs, 0, failures, err
+	}
+
+	_, fullName := models.SplitRepositoryName(repoName)
+
+	if err := limiter.acquire(ctx); err != nil {
+		return nil, 0, nil, err
+	}
+	prs, err := client.FetchOpenPullRequests(ctx, fullName)
+	limiter.release()
 	if err != nil {
-		return nil, fmt.Errorf("fetch open pull requests: %w", err)
+		return nil, 0, nil, fmt.Errorf("fetch open pull requests: %w", err)
 	}
 
-	var result []*models.PullRequest
+	var tracked []gh.PullRequest
+	var unchanged []*models.PullRequest
 	for _, pr := range prs {
 		if !shouldTrackPR(&pr, authorsToTrack) {
 			continue
 		}
-		details, err := fetchPullRequestDetails(repoName, pr.Number, authToken)
+		if known, ok := reusablePullRequest(repo, &pr); ok {
+			unchanged = append(unchanged, known)
+			continue
+		}
+		tracked = append(tracked, pr)
+	}
+
+	details := make([]*models.PullRequest, len(tracked))
+	errs := make([]error, len(tracked))
+
+	var wg sync.WaitGroup
+	for i, pr := range tracked {
+		wg.Go(func() {
+			if err := limiter.acquire(ctx); err != nil {
+				errs[i] = err
+				return
+			}
+			defer limiter.release()
+
+			details[i], _, errs[i] = fetchPullRequestDetails(ctx, client, repoName, pr.Number, repo.IgnoredCommenters, nil)
+		})
+	}
+	wg.Wait()
+
+	// A cancelled fetch is incomplete rather than a set of per-PR failures.
+	if err := ctx.Err(); err != nil {
+		return nil, 0, nil, err
+	}
+
+	result := unchanged
+	var failures []PullRequestFailure
+	for i, pr := range tracked {
+		if errs[i] != nil {
+			failures = append(failures, PullRequestFailure{
+				Number: pr.Number,
+				Err:    fmt.Errorf("fetch pr details for #%d: %w", pr.Number, errs[i]),
+			})
+			continue
+		}
+		result = append(result, details[i])
+	}
+	markRequiredChecks(ctx, limiter, repo, result[len(unchanged):])
+	compareWithBase(ctx, limiter, repo, result[len(unchanged):], false)
+	compareHeads(ctx, limiter, repo, result)
+
+	return result, len(unchanged), failures, nil
+}
+
+// markRequiredChecks flags the checks each pull request's base branch
+// requires, adds an expected check for each required one that hasn't
+// reported yet, and works CiStatus out again from them. Required checks are
+// looked up once per base branch and matched to checks by name. A branch
+// whose required checks can't be fetched is treated as requiring none, which
+// leaves every check counting towards CiStatus.
+func markRequiredChecks(ctx context.Context, limiter limiter, repo TrackedRepository, prs []*models.PullRequest) {
+	_, fullName := models.SplitRepositoryName(repo.Name)
+
+	byBase := map[string][]*models.PullRequest{}
+	for _, pr := range prs {
+		if pr.BaseRef != "" {
+			byBase[pr.BaseRef] = append(byBase[pr.BaseRef], pr)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for base, basePrs := range byBase {
+		wg.Go(func() {
+			if err := limiter.acquire(ctx); err != nil {
+				return
+			}
+			required, err := repo.Client.FetchRequiredStatusChecks(ctx, fullName, base)
+			limiter.release()
+			if err != nil || len(required) == 0 {
+				return
+			}
+
+			for _, pr := range basePrs {
+				pr.Checks = withRequiredChecks(pr.Checks, required)
+				pr.CiStatus = models.CiStatusFromChecks(pr.Checks)
+			}
+		})
+	}
+	wg.Wait()
+}
+
+// withRequiredChecks returns a copy of checks with the ones named in required
+// flagged, plus an expected check for each name in required that none of
+// checks has, kept in the same order as checksFromGitHub.
+func withRequiredChecks(checks []models.Check, required []string) []models.Check {
+	marked := slices.Clone(checks)
+	for i := range marked {
+		marked[i].Required = slices.Contains(required, marked[i].Name)
+	}
+
+	for _, name := range required {
+		reported := slices.ContainsFunc(marked, func(check models.Check) bool { return check.Name == name })
+		if !reported {
+			marked = append(marked, models.Check{Name: name, Status: "expected", Required: true})
+		}
+	}
+
+	slices.SortFunc(marked, func(a, b models.Check) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.App, b.App))
+	})
+	return marked
+}
+
+// compareWithBase works out how many commits each pull request's base branch
+// has that its head doesn't. A count that can't be fetched, and mergeability
+// GitHub hasn't worked out yet, are carried over from repo.Known, so that a
+// pull request doesn't flip between having conflicts and not while GitHub
+// catches up.
+//
+// baseIsCurrent says that BaseSHA is the base branch's current commit, as
+// GraphQL reports it, so a pull request whose head and base commits are both
+// unchanged keeps the known count without a comparison. REST's base.sha can
+// lag behind the branch, so it can't be used to skip one.
+func compareWithBase(ctx context.Context, limiter limiter, repo TrackedRepository, prs []*models.PullRequest, baseIsCurrent bool) {
+	_, fullName := models.SplitRepositoryName(repo.Name)
+
+	var wg sync.WaitGroup
+	for _, pr := range prs {
+		known, ok := repo.Known[pr.Number]
+		if ok {
+			pr.BehindBy = known.BehindBy
+			if pr.Mergeable == nil {
+				pr.Mergeable, pr.MergeableState = known.Mergeable, known.MergeableState
+			}
+		}
+		if pr.BaseRef == "" || pr.HeadSHA == "" {
+			continue
+		}
+		if baseIsCurrent && ok && known.HeadSHA == pr.HeadSHA && known.BaseSHA != "" && known.BaseSHA == pr.BaseSHA {
+			continue
+		}
+
+		wg.Go(func() {
+			if err := limiter.acquire(ctx); err != nil {
+				return
+			}
+			defer limiter.release()
+
+			comparison, err := repo.Client.CompareCommits(ctx, fullName, pr.BaseRef, pr.HeadSHA)
+			if err == nil {
+				pr.BehindBy = comparison.BehindBy
+			}
+		})
+	}
+	wg.Wait()
+}
+
+// compareHeads works out how the head of each pull request moved since the
+// copy in repo.Known was stored. A comparison that fails leaves HeadUpdate
+// unknown rather than failing the pull request, except that GitHub answers 404
+// when the old head no longer exists, which means history was rewritten.
+func compareHeads(ctx context.Context, limiter limiter, repo TrackedRepository, prs []*models.PullRequest) {
+	_, fullName := models.SplitRepositoryName(repo.Name)
+
+	var wg sync.WaitGroup
+	for _, pr := range prs {
+		known, ok := repo.Known[pr.Number]
+		if !ok || known.HeadSHA == "" || pr.HeadSHA == "" || known.HeadSHA == pr.HeadSHA {
+			continue
+		}
+
+		wg.Go(func() {
+			if err := limiter.acquire(ctx); err != nil {
+				return
+			}
+			defer limiter.release()
+
+			comparison, err := repo.Client.CompareCommits(ctx, fullName, known.HeadSHA, pr.HeadSHA)
+			var statusErr *gh.StatusError
+			switch {
+			case errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound:
+				pr.HeadUpdate = models.HeadUpdateRewritten
+			case err == nil:
+				pr.HeadUpdate, pr.NewCommitCount = classifyHeadUpdate(known, pr, comparison)
+			}
+		})
+	}
+	wg.Wait()
+}
+
+// fetchFinalStates looks up the known pull requests among numbers that are
+// missing from result to see whether they were merged or closed, and adds
+// those that were to result.Closed. One that is still open, because its
+// author is no longer tracked, is left out so that it is deleted. One whose
+// state can't be fetched is added to result.Failures, which keeps its row
+// until a later sync can tell.
+func fetchFinalStates(ctx context.Context, limiter limiter, repo TrackedRepository, numbers []int, result *RepositoryPullRequests) {
+	_, fullName := models.SplitRepositoryName(repo.Name)
+
+	accounted := map[int]bool{}
+	for _, pr := range result.PullRequests {
+		accounted[pr.Number] = true
+	}
+	for _, failure := range result.Failures {
+		accounted[failure.Number] = true
+	}
+	for _, pr := range result.Closed {
+		accounted[pr.Number] = true
+	}
+
+	var missing []*models.PullRequest
+	for _, number := range numbers {
+		if known, ok := repo.Known[number]; ok && !accounted[number] {
+			missing = append(missing, known)
+		}
+	}
+
+	closed := make([]*models.PullRequest, len(missing))
+	errs := make([]error, len(missing))
+
+	var wg sync.WaitGroup
+	for i, known := range missing {
+		wg.Go(func() {
+			if err := limiter.acquire(ctx); err != nil {
+				errs[i] = err
+				return
+			}
+			defer limiter.release()
+
+			prDetails, err := repo.Client.FetchPullRequest(ctx, fullName, known.Number)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			closed[i], errs[i] = closedPullRequest(known, prDetails)
+		})
+	}
+	wg.Wait()
+
+	for i, known := range missing {
+		switch {
+		case errs[i] != nil:
+			result.Failures = append(result.Failures, PullRequestFailure{
+				Number: known.Number,
+				Err:    fmt.Errorf("fetch final state of #%d: %w", known.Number, errs[i]),
+			})
+		case closed[i] != nil:
+			result.Closed = append(result.Closed, closed[i])
+		}
+	}
+}
+
+// closedPullRequest returns a copy of the stored pull request with its final
+// state, or nil if it is still open.
+func closedPullRequest(known *models.PullRequest, prDetails *gh.PullRequestDetails) (*models.PullRequest, error) {
+	if prDetails.State == "open" {
+		return nil, nil
+	}
+
+	closedAt, err := parseGitHubTimestamp(prDetails.ClosedAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse closed_at: %w", err)
+	}
+	mergedAt, err := parseGitHubTimestamp(prDetails.MergedAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse merged_at: %w", err)
+	}
+
+	closed := *known
+	closed.State = models.PullRequestStateClosed
+	closed.ClosedAt = closedAt
+	if prDetails.Merged {
+		closed.State = models.PullRequestStateMerged
+		closed.MergedAt = mergedAt
+		if prDetails.MergedBy != nil {
+			closed.MergedBy = prDetails.MergedBy.Login
+		}
+	}
+	return &closed, nil
+}
+
+// classifyHeadUpdate reads a comparison of the stored head with the fetched
+// one. A head that moved any way other than forward counts as a rebase when
+// the base moved too and every one of the pull request's commits was replaced
+// by the same number of new ones.
+func classifyHeadUpdate(known, fetched *models.PullRequest, comparison *gh.Comparison) (models.HeadUpdate, int) {
+	if comparison.Status == "ahead" {
+		return models.HeadUpdateFastForward, comparison.AheadBy
+	}
+
+	rebased := known.BaseSHA != "" && known.BaseSHA != fetched.BaseSHA &&
+		known.CommitCount > 0 && known.CommitCount == fetched.CommitCount &&
+		comparison.BehindBy == known.CommitCount
+	if rebased {
+		return models.HeadUpdateRebased, 0
+	}
+	return models.HeadUpdateRewritten, 0
+}
+
+// checksStartGrace is how long after a pull request last changed its checks
+// may still be about to show up. A pull request fetched without any checks
+// this soon after changing is fetched again in case CI hadn't started yet.
+const checksStartGrace = 10 * time.Minute
+
+// reusablePullRequest returns a copy of the stored pull request for listed
+// when the listing shows it hasn't changed since it was last fetched: the
+// head commit and updated_at are the same, its last fetch succeeded and is
+// newer than repo.RefetchBefore. Pull requests with pending CI are always
+// fetched, because checks finishing doesn't touch updated_at, and so are ones
+// that had no checks yet when fetched within checksStartGrace of changing.
+// Once a later fetch still finds none the repository is taken not to run CI
+// on it. Pull requests GitHub hadn't worked out the mergeability of are
+// fetched again too.
+func reusablePullRequest(repo TrackedRepository, listed *gh.PullRequest) (*models.PullRequest, bool) {
+	known, ok := repo.Known[listed.Number]
+	if !ok || known.IsStale() || known.CiStatus == models.CiStatusPending {
+		return nil, false
+	}
+	if known.CiStatus == models.CiStatusNoChecks && known.LastFetchedAt.Sub(known.UpdatedAt) < checksStartGrace {
+		return nil, false
+	}
+	if known.Mergeable == nil {
+		return nil, false
+	}
+	if known.HeadSHA == "" || known.HeadSHA != listed.Head.SHA {
+		return nil, false
+	}
+	if known.LastFetchedAt.IsZero() || known.LastFetchedAt.Before(repo.RefetchBefore) {
+		return nil, false
+	}
+
+	updatedAt, err := parseGitHubTimestamp(listed.UpdatedAt)
+	if err != nil || !updatedAt.Equal(known.UpdatedAt) {
+		return nil, false
+	}
+
+	reused := *known
+	return &reused, true
+}
+
+// fetchTrackedPullRequestsGraphQL fetches the whole repository with a few
+// GraphQL queries instead of several REST calls per pull request. The queries
+// bring the base branches' required checks along, so unlike the REST path
+// there is no lookup per base branch. Each page holds a limiter slot while it
+// is fetched, the same as a REST request.
+func fetchTrackedPullRequestsGraphQL(ctx context.Context, limiter limiter, client *gh.Client, repoName string, authorsToTrack, ignoredCommenters []string) ([]*models.PullRequest, []PullRequestFailure, error) {
+	_, fullName := models.SplitRepositoryName(repoName)
+
+	if err := limiter.acquire(ctx); err != nil {
+		return nil, nil, err
+	}
+	snapshots, err := client.FetchOpenPullRequestSnapshots(ctx, fullName)
+	limiter.release()
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetch open pull requests: %w", err)
+	}
+
+	var result []*models.PullRequest
+	var failures []PullRequestFailure
+	for _, snapshot := range snapshots {
+		if !shouldTrackPR(&snapshot.Details.PullRequest, authorsToTrack) {
+			continue
+		}
+
+		pr, err := pullRequestFromGitHub(client, repoName, snapshot.Details, snapshot.CIStatuses, ignoredCommenters)
 		if err != nil {
-			return nil, fmt.Errorf("fetch pr details for #%d: %w", pr.Number, err)
+			failures = append(failures, PullRequestFailure{
+				Number: snapshot.Details.Number,
+				Err:    fmt.Errorf("read pr #%d: %w", snapshot.Details.Number, err),
+			})
+			continue
+		}
+		if len(snapshot.RequiredChecks) > 0 {
+			pr.Checks = withRequiredChecks(pr.Checks, snapshot.RequiredChecks)
+			pr.CiStatus = models.CiStatusFromChecks(pr.Checks)
 		}
-		result = append(result, details)
+		result = append(result, pr)
 	}
 
-	return result, nil
+	return result, failures, nil
 }
 
-func fetchPullRequestDetails(repoName string, prID int, authToken string) (*models.PullRequest, error) {
-	prDetails, err := gh.FetchPullRequestDetails(repoName, prID, authToken)
+// limiter bounds the number of fetches in flight. Slots are only held while a
+// request is being made, never while waiting on other fetches, so nested
+// fetches sharing a limiter cannot deadlock.
+type limiter chan struct{}
+
+func newLimiter(parallelism int) limiter {
+	if parallelism <= 0 {
+		parallelism = DefaultParallelism
+	}
+	return make(limiter, parallelism)
+}
+
+func (l limiter) acquire(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case l <- struct{}{}:
+		return nil
+	}
+}
+
+func (l limiter) release() {
+	<-l
+}
+
+// fetchPullRequestDetails fetches a pull request and its CI statuses. When
+// include is set and turns the pull request down, its CI statuses aren't
+// fetched and only GitHub's details are returned.
+func fetchPullRequestDetails(ctx context.Context, client *gh.Client, repoName string, prID int, ignoredCommenters []string, include func(*gh.PullRequestDetails) bool) (*models.PullRequest, *gh.PullRequestDetails, error) {
+	_, fullName := models.SplitRepositoryName(repoName)
+
+	prDetails, err := client.FetchPullRequestDetails(ctx, fullName, prID)
 	if err != nil {
-		return nil, fmt.Errorf("fetch github pr details: %w", err)
+		return nil, nil, fmt.Errorf("fetch github pr details: %w", err)
+	}
+	if include != nil && !include(prDetails) {
+		return nil, prDetails, nil
 	}
 
-	ciStatuses, err := gh.FetchPullRequestCIStatuses(repoName, prID, authToken)
+	ciStatuses, err := client.FetchPullRequestCIStatuses(ctx, fullName, prID, prDetails.Head.SHA)
 	if err != nil {
-		return nil, fmt.Errorf("fetch github pr ci statuses: %w", err)
+		return nil, nil, fmt.Errorf("fetch github pr ci statuses: %w", err)
 	}
 
+	pr, err := pullRequestFromGitHub(client, repoName, prDetails, ciStatuses, ignoredCommenters)
+	return pr, prDetails, err
+}
+
+// pullRequestFromGitHub maps what GitHub returned for a pull request onto the
+// model, whichever backend fetched it.
+func pullRequestFromGitHub(client *gh.Client, repoName string, prDetails *gh.PullRequestDetails, ciStatuses *gh.PullRequestCIStatuses, ignoredCommenters []string) (*models.PullRequest, error) {
+	_, fullName := models.SplitRepositoryName(repoName)
+
 	createdAt, err := parseGitHubTimestamp(prDetails.CreatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("parse pr created_at: %w", err)
@@ -59,11 +665,40 @@ func fetchPullRequestDetails(repoName string, prID int, authToken string) (*mode
 		return nil, fmt.Errorf("parse pr updated_at: %w", err)
 	}
 
+	htmlURL := prDetails.HTMLURL
+	if htmlURL == "" {
+		htmlURL = client.PullRequestURL(fullName, prDetails.Number)
+	}
+
+	headSHA := prDetails.Head.SHA
+	if headSHA == "" {
+		headSHA = ciStatuses.HeadSHA
+	}
+
 	reviewerLogins := make([]string, 0, len(prDetails.RequestedReviewers))
 	for _, r := range prDetails.RequestedReviewers {
 		reviewerLogins = append(reviewerLogins, r.Login)
 	}
 
+	reviews := reviewsFromGitHub(prDetails.Reviews)
+	checks, err := checksFromGitHub(ciStatuses)
+	if err != nil {
+		return nil, err
+	}
+	lastCommentAt, lastCommentAuthor := latestComment(prDetails, ignoredCommenters)
+
+	var lastCommitAt time.Time
+	var headCommitAuthor string
+	if headCommit := prDetails.HeadCommit; headCommit != nil {
+		lastCommitAt, err = parseGitHubTimestamp(headCommit.Commit.Committer.Date)
+		if err != nil {
+			return nil, fmt.Errorf("parse head commit date: %w", err)
+		}
+		if headCommit.Author != nil {
+			headCommitAuthor = headCommit.Author.Login
+		}
+	}
+
 	return &models.PullRequest{
 		Number:             prDetails.Number,
 		Title:              prDetails.Title,
@@ -72,10 +707,24 @@ func fetchPullRequestDetails(repoName string, prID int, authToken string) (*mode
 		Draft:              prDetails.Draft,
 		CreatedAt:          createdAt,
 		UpdatedAt:          updatedAt,
-		CiStatus:           mapCIStatus(ciStatuses),
-		LastCommentAt:      latestCommentTime(prDetails),
-		LastCommitAt:       latestCommitActivityTime(ciStatuses),
+		CiStatus:           models.CiStatusFromChecks(checks),
+		LastCommentAt:      lastCommentAt,
+		LastCommentAuthor:  lastCommentAuthor,
+		LastCommitAt:       lastCommitAt,
 		RequestedReviewers: reviewerLogins,
+		Checks:             checks,
+		Reviews:            reviews,
+		ReviewDecision:     reviewDecision(prDetails.ReviewDecision, reviews, reviewerLogins),
+		HTMLURL:            htmlURL,
+		HeadSHA:            headSHA,
+		HeadCommitAuthor:   headCommitAuthor,
+		BaseRef:            prDetails.Base.Ref,
+		BaseSHA:            prDetails.Base.SHA,
+		CommitCount:        prDetails.CommitCount,
+		Mergeable:          prDetails.Mergeable,
+		MergeableState:     prDetails.MergeableState,
+		State:              models.PullRequestStateOpen,
+		LastFetchedAt:      time.Now().UTC(),
 	}, nil
 }
 
@@ -92,87 +741,171 @@ func parseGitHubTimestamp(value string) (time.Time, error) {
 	return t, nil
 }
 
-func latestCommentTime(prDetails *gh.PullRequestDetails) time.Time {
-	var latest time.Time
+// reviewsFromGitHub reduces a pull request's reviews to each reviewer's
+// current one. A later approval, request for changes or dismissal replaces an
+// earlier review, but a comment-only review only counts when the reviewer has
+// not given a verdict. Pending reviews are drafts nobody else can see yet.
+func reviewsFromGitHub(ghReviews []gh.Review) []models.Review {
+	current := map[string]models.Review{}
 
-	for _, comment := range prDetails.IssueComments {
-		if t, err := parseGitHubTimestamp(comment.UpdatedAt); err == nil && t.After(latest) {
-			latest = t
+	for _, ghReview := range ghReviews {
+		state := models.ReviewState(ghReview.State)
+		if ghReview.User.Login == "" || state == "PENDING" {
+			continue
 		}
-	}
 
-	for _, comment := range prDetails.ReviewComments {
-		if t, err := parseGitHubTimestamp(comment.UpdatedAt); err == nil && t.After(latest) {
-			latest = t
+		submittedAt, err := parseGitHubTimestamp(ghReview.SubmittedAt)
+		if err != nil {
+			continue
+		}
+
+		existing, ok := current[ghReview.User.Login]
+		if ok && state == models.ReviewStateCommented && existing.State != models.ReviewStateCommented {
+			continue
+		}
+		if ok && submittedAt.Before(existing.SubmittedAt) {
+			continue
+		}
+
+		current[ghReview.User.Login] = models.Review{
+			Reviewer:    ghReview.User.Login,
+			State:       state,
+			SubmittedAt: submittedAt,
 		}
 	}
 
-	return latest
+	reviews := make([]models.Review, 0, len(current))
+	for _, reviewer := range slices.Sorted(maps.Keys(current)) {
+		reviews = append(reviews, current[reviewer])
+	}
+	return reviews
 }
 
-func latestCommitActivityTime(ciStatuses *gh.PullRequestCIStatuses) time.Time {
-	var latest time.Time
+// reviewDecision prefers the decision GitHub reports, which accounts for
+// branch protection, and otherwise works it out from the reviews: any request
+// for changes outweighs approvals, and outstanding review requests with no
+// verdict mean a review is still required.
+func reviewDecision(reported string, reviews []models.Review, requestedReviewers []string) models.ReviewDecision {
+	if reported != "" {
+		return models.ReviewDecision(reported)
+	}
 
-	for _, checkRun := range ciStatuses.CheckRuns {
-		if t, err := parseGitHubTimestamp(checkRun.CompletedAt); err == nil && t.After(latest) {
-			latest = t
-		}
-		if t, err := parseGitHubTimestamp(checkRun.StartedAt); err == nil && t.After(latest) {
-			latest = t
+	approved := false
+	for _, review := range reviews {
+		switch review.State {
+		case models.ReviewStateChangesRequested:
+			return models.ReviewDecisionChangesRequested
+		case models.ReviewStateApproved:
+			approved = true
 		}
 	}
 
-	for _, status := range ciStatuses.Statuses {
-		if t, err := parseGitHubTimestamp(status.UpdatedAt); err == nil && t.After(latest) {
-			latest = t
+	switch {
+	case approved:
+		return models.ReviewDecisionApproved
+	case len(requestedReviewers) > 0:
+		return models.ReviewDecisionReviewRequired
+	default:
+		return models.ReviewDecisionNone
+	}
+}
+
+// latestComment returns when the newest issue or review comment that counts
+// was written or edited, and by whom.
+func latestComment(prDetails *gh.PullRequestDetails, ignoredCommenters []string) (time.Time, string) {
+	var latest time.Time
+	var author string
+
+	consider := func(login, updatedAt string) {
+		if isIgnoredCommenter(login, ignoredCommenters) {
+			return
 		}
-		if t, err := parseGitHubTimestamp(status.CreatedAt); err == nil && t.After(latest) {
-			latest = t
+		if t, err := parseGitHubTimestamp(updatedAt); err == nil && t.After(latest) {
+			latest, author = t, login
 		}
 	}
 
-	return latest
-}
-
-func mapCIStatus(ciStatuses *gh.PullRequestCIStatuses) models.CiStatus {
-	if hasFailingCheckRun(ciStatuses.CheckRuns) {
-		return models.CiStatusFailure
+	for _, comment := range prDetails.IssueComments {
+		consider(comment.User.Login, comment.UpdatedAt)
 	}
 
-	if hasPendingCheckRun(ciStatuses.CheckRuns) {
-		return models.CiStatusPending
+	for _, comment := range prDetails.ReviewComments {
+		consider(comment.User.Login, comment.UpdatedAt)
 	}
 
-	switch ciStatuses.CombinedState {
-	case "success":
-		return models.CiStatusSuccess
-	case "failure", "error":
-		return models.CiStatusFailure
-	default:
-		return models.CiStatusPending
+	return latest, author
+}
+
+// isIgnoredCommenter reports whether comments by login should be left out of
+// LastCommentAt: GitHub Apps, whose logins end in "[bot]", and the logins in
+// ignoredCommenters, which GitHub compares without regard to case.
+func isIgnoredCommenter(login string, ignoredCommenters []string) bool {
+	if strings.HasSuffix(login, "[bot]") {
+		return true
 	}
+	return slices.ContainsFunc(ignoredCommenters, func(ignored string) bool {
+		return strings.EqualFold(ignored, login)
+	})
 }
 
-func hasFailingCheckRun(checkRuns []gh.CheckRun) bool {
-	for _, checkRun := range checkRuns {
-		switch checkRun.Conclusion {
-		case "failure", "timed_out", "cancelled", "startup_failure", "action_required", "stale":
-			return true
+// checksFromGitHub lists the check runs and commit statuses on the head
+// commit, ordered by name and then app.
+func checksFromGitHub(ciStatuses *gh.PullRequestCIStatuses) ([]models.Check, error) {
+	checks := make([]models.Check, 0, len(ciStatuses.CheckRuns)+len(ciStatuses.Statuses))
+
+	for _, checkRun := range ciStatuses.CheckRuns {
+		startedAt, err := parseGitHubTimestamp(checkRun.StartedAt)
+		if err != nil {
+			return nil, fmt.Errorf("parse check run %s started_at: %w", checkRun.Name, err)
+		}
+		completedAt, err := parseGitHubTimestamp(checkRun.CompletedAt)
+		if err != nil {
+			return nil, fmt.Errorf("parse check run %s completed_at: %w", checkRun.Name, err)
+		}
+
+		detailsURL := checkRun.DetailsURL
+		if detailsURL == "" {
+			detailsURL = checkRun.HTMLURL
 		}
+		checks = append(checks, models.Check{
+			Name:        checkRun.Name,
+			App:         checkRun.App.Name,
+			Status:      checkRun.Status,
+			Conclusion:  checkRun.Conclusion,
+			DetailsURL:  detailsURL,
+			StartedAt:   startedAt,
+			CompletedAt: completedAt,
+		})
 	}
 
-	return false
-}
+	for _, status := range ciStatuses.Statuses {
+		createdAt, err := parseGitHubTimestamp(status.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("parse status %s created_at: %w", status.Context, err)
+		}
 
-func hasPendingCheckRun(checkRuns []gh.CheckRun) bool {
-	for _, checkRun := range checkRuns {
-		switch checkRun.Status {
-		case "queued", "in_progress", "waiting", "requested", "pending":
-			return true
+		check := models.Check{
+			Name:       status.Context,
+			Status:     "pending",
+			DetailsURL: status.TargetURL,
+			StartedAt:  createdAt,
+		}
+		if status.State != "pending" {
+			updatedAt, err := parseGitHubTimestamp(status.UpdatedAt)
+			if err != nil {
+				return nil, fmt.Errorf("parse status %s updated_at: %w", status.Context, err)
+			}
+			check.Status = "completed"
+			check.Conclusion = status.State
+			check.CompletedAt = updatedAt
 		}
+		checks = append(checks, check)
 	}
 
-	return false
+	slices.SortFunc(checks, func(a, b models.Check) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.App, b.App))
+	})
+	return checks, nil
 }
 
 func shouldTrackPR(pr *gh.PullRequest, authorsToTrack []string) bool {
@@ -0,0 +1,504 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	gh "git.rileymathews.com/riley/pr-tracker/internal/github"
+	"git.rileymathews.com/riley/pr-tracker/internal/github/githubtest"
+	"git.rileymathews.com/riley/pr-tracker/internal/models"
+)
+
+// TestReusablePullRequest covers when a stored pull request is carried over
+// instead of being fetched again.
+func TestReusablePullRequest(t *testing.T) {
+	updatedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	fetchedAt := updatedAt.Add(time.Hour)
+
+	mergeable := true
+
+	stored := func(change func(pr *models.PullRequest)) map[int]*models.PullRequest {
+		pr := &models.PullRequest{
+			Number:        7,
+			Repository:    "acme/repo",
+			UpdatedAt:     updatedAt,
+			CiStatus:      models.CiStatusSuccess,
+			HeadSHA:       "abc123",
+			LastFetchedAt: fetchedAt,
+			Mergeable:     &mergeable,
+		}
+		if change != nil {
+			change(pr)
+		}
+		return map[int]*models.PullRequest{pr.Number: pr}
+	}
+
+	listed := gh.PullRequest{Number: 7, UpdatedAt: "2025-03-01T12:00:00Z"}
+	listed.Head.SHA = "abc123"
+
+	tests := []struct {
+		name          string
+		known         map[int]*models.PullRequest
+		refetchBefore time.Time
+		listedSHA     string
+		listedUpdated string
+		want          bool
+	}{
+		{name: "unchanged", known: stored(nil), want: true},
+		{name: "not stored", known: nil},
+		{name: "new commits", known: stored(nil), listedSHA: "def456"},
+		{name: "updated", known: stored(nil), listedUpdated: "2025-03-01T12:05:00Z"},
+		{name: "ci pending", known: stored(func(pr *models.PullRequest) { pr.CiStatus = models.CiStatusPending })},
+		{name: "no checks", known: stored(func(pr *models.PullRequest) { pr.CiStatus = models.CiStatusNoChecks }), want: true},
+		{name: "no checks yet", known: stored(func(pr *models.PullRequest) {
+			pr.CiStatus = models.CiStatusNoChecks
+			pr.LastFetchedAt = updatedAt.Add(time.Minute)
+		})},
+		{name: "mergeability unknown", known: stored(func(pr *models.PullRequest) { pr.Mergeable = nil })},
+		{name: "stale", known: stored(func(pr *models.PullRequest) { pr.SyncError = "boom" })},
+		{name: "head unknown", known: stored(func(pr *models.PullRequest) { pr.HeadSHA = "" })},
+		{name: "due full resync", known: stored(nil), refetchBefore: fetchedAt.Add(time.Minute)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := listed
+			if tt.listedSHA != "" {
+				pr.Head.SHA = tt.listedSHA
+			}
+			if tt.listedUpdated != "" {
+				pr.UpdatedAt = tt.listedUpdated
+			}
+
+			repo := TrackedRepository{Name: "acme/repo", Known: tt.known, RefetchBefore: tt.refetchBefore}
+			reused, ok := reusablePullRequest(repo, &pr)
+			if ok != tt.want {
+				t.Fatalf("expected reuse %v, got %v", tt.want, ok)
+			}
+			if ok && reused == tt.known[7] {
+				t.Error("expected a copy of the stored pull request")
+			}
+		})
+	}
+}
+
+// TestReviewsFromGitHub covers reducing a review history to each reviewer's
+// current review and the decision derived from it.
+func TestReviewsFromGitHub(t *testing.T) {
+	review := func(login, state, submittedAt string) gh.Review {
+		r := gh.Review{State: state, SubmittedAt: submittedAt}
+		r.User.Login = login
+		return r
+	}
+
+	reviews := reviewsFromGitHub([]gh.Review{
+		review("carol", "CHANGES_REQUESTED", "2025-03-01T10:00:00Z"),
+		review("bob", "COMMENTED", "2025-03-01T10:30:00Z"),
+		review("carol", "APPROVED", "2025-03-01T11:00:00Z"),
+		review("carol", "COMMENTED", "2025-03-01T12:00:00Z"),
+		review("dave", "PENDING", ""),
+		review("erin", "APPROVED", "2025-03-01T09:00:00Z"),
+		review("erin", "DISMISSED", "2025-03-01T13:00:00Z"),
+	})
+
+	want := []models.Review{
+		{Reviewer: "bob", State: models.ReviewStateCommented, SubmittedAt: time.Date(2025, 3, 1, 10, 30, 0, 0, time.UTC)},
+		{Reviewer: "carol", State: models.ReviewStateApproved, SubmittedAt: time.Date(2025, 3, 1, 11, 0, 0, 0, time.UTC)},
+		{Reviewer: "erin", State: models.ReviewStateDismissed, SubmittedAt: time.Date(2025, 3, 1, 13, 0, 0, 0, time.UTC)},
+	}
+	if len(reviews) != len(want) {
+		t.Fatalf("expected %d reviews, got %+v", len(want), reviews)
+	}
+	for i := range want {
+		if reviews[i].Reviewer != want[i].Reviewer || reviews[i].State != want[i].State || !reviews[i].SubmittedAt.Equal(want[i].SubmittedAt) {
+			t.Errorf("review %d: expected %+v, got %+v", i, want[i], reviews[i])
+		}
+	}
+
+	tests := []struct {
+		name      string
+		reported  string
+		reviews   []models.Review
+		requested []string
+		want      models.ReviewDecision
+	}{
+		{name: "reported", reported: "REVIEW_REQUIRED", reviews: reviews, want: models.ReviewDecisionReviewRequired},
+		{name: "approved", reviews: reviews, want: models.ReviewDecisionApproved},
+		{name: "changes requested", reviews: append(slices.Clone(reviews), models.Review{Reviewer: "frank", State: models.ReviewStateChangesRequested}), want: models.ReviewDecisionChangesRequested},
+		{name: "awaiting review", requested: []string{"carol"}, want: models.ReviewDecisionReviewRequired},
+		{name: "no reviews", want: models.ReviewDecisionNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := reviewDecision(tt.reported, tt.reviews, tt.requested); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestClassifyHeadUpdate covers how a moved head commit is told apart as
+// new commits, a rebase or rewritten history.
+func TestClassifyHeadUpdate(t *testing.T) {
+	known := &models.PullRequest{HeadSHA: "aaa", BaseSHA: "base1", CommitCount: 2}
+
+	tests := []struct {
+		name       string
+		fetched    models.PullRequest
+		comparison gh.Comparison
+		want       models.HeadUpdate
+		wantCount  int
+	}{
+		{
+			name:       "new commits on top",
+			fetched:    models.PullRequest{HeadSHA: "bbb", BaseSHA: "base1", CommitCount: 4},
+			comparison: gh.Comparison{Status: "ahead", AheadBy: 2},
+			want:       models.HeadUpdateFastForward,
+			wantCount:  2,
+		},
+		{
+			name:       "rebased onto a new base",
+			fetched:    models.PullRequest{HeadSHA: "bbb", BaseSHA: "base2", CommitCount: 2},
+			comparison: gh.Comparison{Status: "diverged", AheadBy: 5, BehindBy: 2},
+			want:       models.HeadUpdateRebased,
+		},
+		{
+			name:       "amended on the same base",
+			fetched:    models.PullRequest{HeadSHA: "bbb", BaseSHA: "base1", CommitCount: 2},
+			comparison: gh.Comparison{Status: "diverged", AheadBy: 1, BehindBy: 1},
+			want:       models.HeadUpdateRewritten,
+		},
+		{
+			name:       "rebased and squashed",
+			fetched:    models.PullRequest{HeadSHA: "bbb", BaseSHA: "base2", CommitCount: 1},
+			comparison: gh.Comparison{Status: "diverged", AheadBy: 4, BehindBy: 2},
+			want:       models.HeadUpdateRewritten,
+		},
+		{
+			name:       "reset to an older commit",
+			fetched:    models.PullRequest{HeadSHA: "bbb", BaseSHA: "base1", CommitCount: 1},
+			comparison: gh.Comparison{Status: "behind", BehindBy: 1},
+			want:       models.HeadUpdateRewritten,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, count := classifyHeadUpdate(known, &tt.fetched, &tt.comparison)
+			if got != tt.want || count != tt.wantCount {
+				t.Errorf("expected %q with %d commits, got %q with %d", tt.want, tt.wantCount, got, count)
+			}
+		})
+	}
+}
+
+// TestLatestComment covers which comments count towards LastCommentAt.
+func TestLatestComment(t *testing.T) {
+	issueComment := func(login, updatedAt string) gh.IssueComment {
+		c := gh.IssueComment{UpdatedAt: updatedAt}
+		c.User.Login = login
+		return c
+	}
+	reviewComment := func(login, updatedAt string) gh.ReviewComment {
+		c := gh.ReviewComment{UpdatedAt: updatedAt}
+		c.User.Login = login
+		return c
+	}
+
+	details := &gh.PullRequestDetails{
+		IssueComments: []gh.IssueComment{
+			issueComment("bob", "2025-03-01T10:00:00Z"),
+			issueComment("codecov[bot]", "2025-03-01T13:00:00Z"),
+		},
+		ReviewComments: []gh.ReviewComment{
+			reviewComment("carol", "2025-03-01T11:00:00Z"),
+			reviewComment("Alice", "2025-03-01T12:00:00Z"),
+		},
+	}
+
+	tests := []struct {
+		name       string
+		ignored    []string
+		wantAt     time.Time
+		wantAuthor string
+	}{
+		{
+			name:       "bots are always ignored",
+			wantAt:     time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+			wantAuthor: "Alice",
+		},
+		{
+			name:       "ignored logins match regardless of case",
+			ignored:    []string{"alice"},
+			wantAt:     time.Date(2025, 3, 1, 11, 0, 0, 0, time.UTC),
+			wantAuthor: "carol",
+		},
+		{
+			name:    "everyone ignored",
+			ignored: []string{"alice", "bob", "carol"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at, author := latestComment(details, tt.ignored)
+			if !at.Equal(tt.wantAt) || author != tt.wantAuthor {
+				t.Errorf("expected %s by %q, got %s by %q", tt.wantAt, tt.wantAuthor, at, author)
+			}
+		})
+	}
+}
+
+// TestChecksFromGitHub covers mapping check runs and commit statuses onto
+// checks.
+func TestChecksFromGitHub(t *testing.T) {
+	checkRun := gh.CheckRun{
+		Name:        "test",
+		Status:      "completed",
+		Conclusion:  "failure",
+		HTMLURL:     "https://github.com/acme/widgets/runs/1",
+		StartedAt:   "2025-03-01T10:00:00Z",
+		CompletedAt: "2025-03-01T10:05:00Z",
+	}
+	checkRun.App.Name = "GitHub Actions"
+
+	checks, err := checksFromGitHub(&gh.PullRequestCIStatuses{
+		CheckRuns: []gh.CheckRun{checkRun},
+		Statuses: []gh.CommitStatusContext{
+			{Context: "deploy", State: "pending", TargetURL: "https://ci.example.com/2", CreatedAt: "2025-03-01T10:01:00Z", UpdatedAt: "2025-03-01T10:01:00Z"},
+			{Context: "coverage", State: "success", CreatedAt: "2025-03-01T10:00:00Z", UpdatedAt: "2025-03-01T10:02:00Z"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("map checks: %v", err)
+	}
+
+	want := []models.Check{
+		{Name: "coverage", Status: "completed", Conclusion: "success", StartedAt: time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC), CompletedAt: time.Date(2025, 3, 1, 10, 2, 0, 0, time.UTC)},
+		{Name: "deploy", Status: "pending", DetailsURL: "https://ci.example.com/2", StartedAt: time.Date(2025, 3, 1, 10, 1, 0, 0, time.UTC)},
+		{Name: "test", App: "GitHub Actions", Status: "completed", Conclusion: "failure", DetailsURL: "https://github.com/acme/widgets/runs/1", StartedAt: time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC), CompletedAt: time.Date(2025, 3, 1, 10, 5, 0, 0, time.UTC)},
+	}
+	if len(checks) != len(want) {
+		t.Fatalf("expected %d checks, got %+v", len(want), checks)
+	}
+	for i := range want {
+		if checks[i] != want[i] {
+			t.Errorf("check %d: expected %+v, got %+v", i, want[i], checks[i])
+		}
+	}
+
+	outcomes := []models.CheckOutcome{models.CheckOutcomePassing, models.CheckOutcomePending, models.CheckOutcomeFailing}
+	for i, outcome := range outcomes {
+		if got := checks[i].Outcome(); got != outcome {
+			t.Errorf("check %s: expected %s, got %s", checks[i].Name, outcome, got)
+		}
+	}
+}
+
+// TestCiStatusWithRequiredChecks covers how flagging the base branch's
+// required checks changes the CI status worked out from a pull request's
+// checks.
+func TestCiStatusWithRequiredChecks(t *testing.T) {
+	check := func(name, status, conclusion string) models.Check {
+		return models.Check{Name: name, Status: status, Conclusion: conclusion}
+	}
+	build := check("build", "completed", "success")
+	lint := check("lint", "completed", "failure")
+	docs := check("docs", "completed", "skipped")
+
+	tests := []struct {
+		name     string
+		checks   []models.Check
+		required []string
+		want     models.CiStatus
+	}{
+		{name: "no checks", want: models.CiStatusNoChecks},
+		{name: "unprotected failing", checks: []models.Check{build, lint}, want: models.CiStatusFailure},
+		{name: "unprotected passing", checks: []models.Check{build, docs}, want: models.CiStatusSuccess},
+		{name: "all skipped", checks: []models.Check{docs}, want: models.CiStatusNeutral},
+		{name: "only optional failing", checks: []models.Check{build, lint}, required: []string{"build"}, want: models.CiStatusOptionalFailing},
+		{name: "required failing", checks: []models.Check{build, lint}, required: []string{"lint"}, want: models.CiStatusFailure},
+		{name: "required running", checks: []models.Check{check("build", "in_progress", ""), lint}, required: []string{"build"}, want: models.CiStatusPending},
+		{name: "required missing", checks: []models.Check{lint}, required: []string{"build"}, want: models.CiStatusPending},
+		{name: "required missing without checks", required: []string{"build"}, want: models.CiStatusPending},
+		{name: "required skipped", checks: []models.Check{build, docs}, required: []string{"docs"}, want: models.CiStatusNeutral},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checks := tt.checks
+			if len(tt.required) > 0 {
+				checks = withRequiredChecks(checks, tt.required)
+			}
+			if got := models.CiStatusFromChecks(checks); got != tt.want {
+				t.Errorf("expected %s, got %s for %+v", tt.want, got, checks)
+			}
+		})
+	}
+}
+
+// TestMarkRequiredChecks covers looking up the base branch's required checks,
+// including branch protection that can't be read, which GitHub answers with a
+// 403 for anyone without admin access.
+func TestMarkRequiredChecks(t *testing.T) {
+	const protectionPath = "/repos/acme/widgets/branches/main/protection/required_status_checks"
+
+	tests := []struct {
+		name         string
+		setup        func(server *githubtest.Server)
+		want         models.CiStatus
+		wantRequired []string
+	}{
+		{
+			name:         "protected",
+			setup:        func(server *githubtest.Server) { server.SetBranchProtection("acme/widgets", "main", "build") },
+			want:         models.CiStatusOptionalFailing,
+			wantRequired: []string{"build"},
+		},
+		{
+			name:         "required by a ruleset",
+			setup:        func(server *githubtest.Server) { server.SetRulesetRequiredChecks("acme/widgets", "main", "build") },
+			want:         models.CiStatusOptionalFailing,
+			wantRequired: []string{"build"},
+		},
+		{
+			name:  "not protected",
+			setup: func(server *githubtest.Server) {},
+			want:  models.CiStatusFailure,
+		},
+		{
+			name: "protection forbidden",
+			setup: func(server *githubtest.Server) {
+				server.SetBranchProtection("acme/widgets", "main", "build")
+				server.Fail(protectionPath, http.StatusForbidden, 0)
+			},
+			want: models.CiStatusFailure,
+		},
+		{
+			name: "protection unavailable",
+			setup: func(server *githubtest.Server) {
+				server.SetRulesetRequiredChecks("acme/widgets", "main", "build")
+				server.Fail(protectionPath, http.StatusInternalServerError, 0)
+			},
+			want: models.CiStatusFailure,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := githubtest.NewServer(t)
+			tt.setup(server)
+			config := server.Config()
+			config.RetryPolicy = gh.RetryPolicy{MaxAttempts: 1}
+			client, err := gh.NewClient(config)
+			if err != nil {
+				t.Fatalf("create client: %v", err)
+			}
+
+			checks := []models.Check{
+				{Name: "build", Status: "completed", Conclusion: "success"},
+				{Name: "lint", Status: "completed", Conclusion: "failure"},
+			}
+			pr := &models.PullRequest{Number: 1, BaseRef: "main", Checks: checks, CiStatus: models.CiStatusFromChecks(checks)}
+			repo := TrackedRepository{Name: "acme/widgets", Client: client}
+			markRequiredChecks(context.Background(), newLimiter(1), repo, []*models.PullRequest{pr})
+
+			if !slices.Contains(server.Requests(), "GET "+protectionPath) {
+				t.Errorf("expected branch protection to be looked up, got %v", server.Requests())
+			}
+			if pr.CiStatus != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, pr.CiStatus)
+			}
+			var required []string
+			for _, check := range pr.Checks {
+				if check.Required {
+					required = append(required, check.Name)
+				}
+			}
+			if !slices.Equal(required, tt.wantRequired) {
+				t.Errorf("expected required checks %v, got %v", tt.wantRequired, required)
+			}
+		})
+	}
+}
+
+// TestFetchTrackedPullRequestsGraphQL_RequiredChecks verifies that the
+// GraphQL backend takes the base branch's required checks from its query
+// instead of looking them up over REST.
+func TestFetchTrackedPullRequestsGraphQL_RequiredChecks(t *testing.T) {
+	const page = `{"data":{"repository":{"pullRequests":{
+  "pageInfo":{"hasNextPage":false,"endCursor":"cursor-1"},
+  "nodes":[{
+    "number":1,"title":"Add feature","state":"OPEN","url":"https://github.com/acme/widgets/pull/1",
+    "createdAt":"2025-01-01T10:00:00Z","updatedAt":"2025-01-02T10:00:00Z",
+    "author":{"login":"alice"},"baseRefName":"main","baseRefOid":"base1",
+    "baseRef":{"refUpdateRule":{"requiredStatusCheckContexts":["build"]},"rules":{"nodes":[]}},
+    "commits":{"totalCount":1,"nodes":[{"commit":{"oid":"head1","committedDate":"2025-01-01T10:00:00Z","statusCheckRollup":{"contexts":{"nodes":[
+      {"__typename":"CheckRun","databaseId":1,"name":"build","status":"COMPLETED","conclusion":"SUCCESS"},
+      {"__typename":"CheckRun","databaseId":2,"name":"lint","status":"COMPLETED","conclusion":"FAILURE"}
+    ]}}}}]}
+  }]
+}}}}`
+
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	client, err := gh.NewClient(gh.Config{BaseURL: server.URL + "/api/v3", Token: "token", HTTPClient: server.Client(), Backend: gh.BackendGraphQL})
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+
+	prs, failures, err := fetchTrackedPullRequestsGraphQL(context.Background(), newLimiter(1), client, "acme/widgets", []string{"alice"}, nil)
+	if err != nil || len(failures) != 0 {
+		t.Fatalf("fetch pull requests: %v %v", err, failures)
+	}
+	if len(prs) != 1 || prs[0].CiStatus != models.CiStatusOptionalFailing {
+		t.Fatalf("expected one pull request with only optional checks failing, got %+v", prs)
+	}
+	if !slices.Equal(requests, []string{"POST /api/graphql"}) {
+		t.Errorf("expected a single GraphQL request, got %v", requests)
+	}
+}
+
+// TestCompareWithBase verifies that, with the base branch's current commit
+// known, the base branch is only compared with pull requests whose head or
+// base moved since they were stored, and otherwise with every pull request.
+func TestCompareWithBase(t *testing.T) {
+	server := githubtest.NewServer(t)
+	server.SetComparison("acme/widgets", "main", "head1", githubtest.Comparison{Status: "behind", BehindBy: 2})
+	server.SetComparison("acme/widgets", "main", "head2", githubtest.Comparison{Status: "behind", BehindBy: 5})
+
+	repo := TrackedRepository{
+		Name:   "acme/widgets",
+		Client: server.Client(t),
+		Known: map[int]*models.PullRequest{
+			1: {Number: 1, HeadSHA: "head1", BaseSHA: "base1", BehindBy: 3},
+			2: {Number: 2, HeadSHA: "head2", BaseSHA: "base1", BehindBy: 3},
+		},
+	}
+	unchanged := &models.PullRequest{Number: 1, BaseRef: "main", HeadSHA: "head1", BaseSHA: "base1"}
+	baseMoved := &models.PullRequest{Number: 2, BaseRef: "main", HeadSHA: "head2", BaseSHA: "base2"}
+	compareWithBase(context.Background(), newLimiter(1), repo, []*models.PullRequest{unchanged, baseMoved}, true)
+
+	if unchanged.BehindBy != 3 || baseMoved.BehindBy != 5 {
+		t.Errorf("expected behind by 3 and 5, got %d and %d", unchanged.BehindBy, baseMoved.BehindBy)
+	}
+	if requests := server.Requests(); !slices.Equal(requests, []string{"GET /repos/acme/widgets/compare/main...head2?per_page=1"}) {
+		t.Errorf("expected only the pull request whose base moved to be compared, got %v", requests)
+	}
+
+	// REST's base.sha can lag behind the branch, so it is compared anyway.
+	unchanged = &models.PullRequest{Number: 1, BaseRef: "main", HeadSHA: "head1", BaseSHA: "base1"}
+	compareWithBase(context.Background(), newLimiter(1), repo, []*models.PullRequest{unchanged}, false)
+	if unchanged.BehindBy != 2 {
+		t.Errorf("expected behind by 2 without the current base, got %d", unchanged.BehindBy)
+	}
+}
@@ -0,0 +1,89 @@
+package sync
+
+import (
+	"fmt"
+
+	gh "git.rileymathews.com/riley/pr-tracker/internal/github"
+	"git.rileymathews.com/riley/pr-tracker/internal/models"
+)
+
+// NewClient builds a client for host. userToken, the authenticated user's
+// github.com token, is only used for github.com when it has no token of its
+// own; it is never sent to another host. shared supplies the settings that
+// don't depend on the host, such as the response cache; the host's own
+// settings replace the rest of it.
+func NewClient(host models.GitHubHost, userToken string, shared gh.Config) (*gh.Client, error) {
+	token := host.AccessToken
+	if token == "" && host.Host == models.DefaultHost {
+		token = userToken
+	}
+
+	config := shared
+	config.BaseURL = host.APIURL
+	config.WebURL = host.WebURL
+	config.Token = token
+	config.ProxyURL = host.ProxyURL
+	config.CAFile = host.CAFile
+	config.Backend = gh.Backend(host.Backend)
+	return gh.NewClient(config)
+}
+
+// hostClients holds a client for every host repositories can be synced from.
+// Hosts whose settings could not be turned into a client keep the error so
+// only their repositories fail.
+type hostClients struct {
+	clients map[string]*gh.Client
+	errs    map[string]error
+}
+
+// newHostClients builds clients for the configured hosts, each on top of
+// shared. github.com is always available, with default settings unless it has
+// been configured explicitly.
+func newHostClients(hosts []models.GitHubHost, token string, shared gh.Config) *hostClients {
+	hc := &hostClients{clients: map[string]*gh.Client{}, errs: map[string]error{}}
+
+	configured := false
+	for _, host := range hosts {
+		configured = configured || host.Host == models.DefaultHost
+		hc.add(host, token, shared)
+	}
+	if !configured {
+		hc.add(models.DefaultGitHubHost(models.DefaultHost), token, shared)
+	}
+
+	return hc
+}
+
+func (hc *hostClients) add(host models.GitHubHost, token string, shared gh.Config) {
+	client, err := NewClient(host, token, shared)
+	if err != nil {
+		hc.errs[host.Host] = fmt.Errorf("configure host %s: %w", host.Host, err)
+		return
+	}
+	hc.clients[host.Host] = client
+}
+
+// defaultClient returns the github.com client. It is safe to call on a nil
+// hostClients, which has no clients.
+func (hc *hostClients) defaultClient() (*gh.Client, bool) {
+	if hc == nil {
+		return nil, false
+	}
+
+	client, ok := hc.clients[models.DefaultHost]
+	return client, ok
+}
+
+// forRepository returns the client for the host repoName lives on.
+func (hc *hostClients) forRepository(repoName string) (*gh.Client, error) {
+	host, _ := models.SplitRepositoryName(repoName)
+	if err, ok := hc.errs[host]; ok {
+		return nil, err
+	}
+
+	client, ok := hc.clients[host]
+	if !ok {
+		return nil, fmt.Errorf("no GitHub host configured for %s, add it with 'cli hosts add %s'", host, host)
+	}
+	return client, nil
+}
@@ -0,0 +1,58 @@
+package sync
+
+import (
+	"strings"
+	"testing"
+
+	gh "git.rileymathews.com/riley/pr-tracker/internal/github"
+	"git.rileymathews.com/riley/pr-tracker/internal/models"
+)
+
+// TestHostClients_ForRepository covers picking the client for a repository
+// by the host prefix of its name.
+func TestHostClients_ForRepository(t *testing.T) {
+	clients := newHostClients([]models.GitHubHost{
+		{Host: "ghe.example.com", APIURL: "https://ghe.example.com/api/v3", WebURL: "https://ghe.example.com", AccessToken: "ghe-token"},
+		{Host: "broken.example.com", APIURL: "https://broken.example.com/api/v3", AccessToken: "token", CAFile: "/does/not/exist.pem"},
+	}, "user-token", gh.Config{})
+
+	tests := []struct {
+		repoName string
+		wantWeb  string
+		wantErr  string
+	}{
+		{repoName: "platform/api", wantWeb: "https://github.com"},
+		{repoName: "ghe.example.com/platform/api", wantWeb: "https://ghe.example.com"},
+		{repoName: "broken.example.com/platform/api", wantErr: "configure host broken.example.com"},
+		{repoName: "unknown.example.com/platform/api", wantErr: "no GitHub host configured for unknown.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.repoName, func(t *testing.T) {
+			client, err := clients.forRepository(tt.repoName)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if client.WebURL() != tt.wantWeb {
+				t.Errorf("expected client for %s, got %s", tt.wantWeb, client.WebURL())
+			}
+		})
+	}
+}
+
+// TestNewClient_UserTokenOnlyForGitHubCom verifies that the github.com token
+// is never used for an enterprise host that has no token of its own.
+func TestNewClient_UserTokenOnlyForGitHubCom(t *testing.T) {
+	if _, err := NewClient(models.DefaultGitHubHost(models.DefaultHost), "user-token", gh.Config{}); err != nil {
+		t.Errorf("expected github.com to fall back to the user token: %v", err)
+	}
+	if _, err := NewClient(models.DefaultGitHubHost("ghe.example.com"), "user-token", gh.Config{}); err == nil {
+		t.Error("expected an enterprise host without a token to be rejected")
+	}
+}
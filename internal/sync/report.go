@@ -0,0 +1,193 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	gh "git.rileymathews.com/riley/pr-tracker/internal/github"
+	"git.rileymathews.com/riley/pr-tracker/internal/models"
+	"git.rileymathews.com/riley/pr-tracker/internal/service"
+)
+
+// SyncReport describes the outcome of a single sync run across every tracked
+// repository.
+type SyncReport struct {
+	StartedAt  time.Time
+	FinishedAt time.Time
+	APICalls   int64
+	// CachedResponses is how many of APICalls were answered with
+	// 304 Not Modified and served from the response cache.
+	CachedResponses int64
+	// Retries is how many requests were sent again after a transient
+	// failure.
+	Retries int64
+	// RateLimit is the github.com REST API budget left when the run
+	// finished.
+	RateLimit    gh.RateLimitStatus
+	Repositories []RepositoryReport
+}
+
+// RepositoryReport describes what a sync changed for one repository. Err is
+// set when the repository could not be synced, in which case none of its
+// changes were written. FailedPrs lists pull requests that could not be
+// fetched and were marked stale while the rest of the repository synced.
+// Unchanged is how many pull requests were skipped because the open pull
+// request listing showed nothing new since they were last fetched.
+// Events lists what changed about the new and updated pull requests.
+// Duration is the time spent fetching the repository and APICalls the number
+// of GitHub requests it took.
+type RepositoryReport struct {
+	Repository string
+	NewPrs     []*models.PullRequest
+	UpdatedPrs []*models.PullRequest
+	// ArchivedPrs were merged or closed, and DeletedPrs are still open but
+	// no longer tracked.
+	ArchivedPrs []*models.PullRequest
+	DeletedPrs  []*models.PullRequest
+	FailedPrs   []service.PullRequestFailure
+	Events      []models.ChangeEvent
+	Unchanged   int
+	Err         error
+	Duration    time.Duration
+	APICalls    int64
+}
+
+func (report *SyncReport) Duration() time.Duration {
+	return report.FinishedAt.Sub(report.StartedAt)
+}
+
+// Failed returns the repositories that could not be synced.
+func (report *SyncReport) Failed() []RepositoryReport {
+	var failed []RepositoryReport
+	for _, repoReport := range report.Repositories {
+		if repoReport.Err != nil {
+			failed = append(failed, repoReport)
+		}
+	}
+	return failed
+}
+
+// StaleCount is the number of pull requests that could not be fetched and
+// were left with their last known data.
+func (report *SyncReport) StaleCount() int {
+	var count int
+	for _, repoReport := range report.Repositories {
+		count += len(repoReport.FailedPrs)
+	}
+	return count
+}
+
+// UnchangedCount is the number of pull requests that were not fetched again
+// because nothing had changed since the last sync.
+func (report *SyncReport) UnchangedCount() int {
+	var count int
+	for _, repoReport := range report.Repositories {
+		count += repoReport.Unchanged
+	}
+	return count
+}
+
+// Totals sums the new, updated and deleted pull requests across every
+// repository that synced successfully.
+func (report *SyncReport) Totals() (newCount, updatedCount, deletedCount int) {
+	for _, repoReport := range report.Repositories {
+		newCount += len(repoReport.NewPrs)
+		updatedCount += len(repoReport.UpdatedPrs)
+		deletedCount += len(repoReport.DeletedPrs)
+	}
+	return newCount, updatedCount, deletedCount
+}
+
+// ArchivedCount is the number of pull requests that were merged or closed
+// since the last sync.
+func (report *SyncReport) ArchivedCount() int {
+	var count int
+	for _, repoReport := range report.Repositories {
+		count += len(repoReport.ArchivedPrs)
+	}
+	return count
+}
+
+// PullRequestEvents returns the events for one of the repository's pull
+// requests.
+func (repoReport RepositoryReport) PullRequestEvents(number int) []models.ChangeEvent {
+	var events []models.ChangeEvent
+	for _, event := range repoReport.Events {
+		if event.Number == number {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// syncRun converts the report into the record persisted in the sync history.
+// runErr is the error Run returned, if any.
+func (report *SyncReport) syncRun(runErr error) *models.SyncRun {
+	newCount, updatedCount, deletedCount := report.Totals()
+	run := &models.SyncRun{
+		StartedAt:       report.StartedAt,
+		FinishedAt:      report.FinishedAt,
+		Outcome:         models.SyncOutcomeSuccess,
+		NewCount:        newCount,
+		UpdatedCount:    updatedCount,
+		RemovedCount:    report.ArchivedCount() + deletedCount,
+		StaleCount:      report.StaleCount(),
+		APICalls:        report.APICalls,
+		CachedResponses: report.CachedResponses,
+		Retries:         report.Retries,
+	}
+	if report.RateLimit.Known() {
+		run.RateLimit = report.RateLimit.Limit
+		run.RateLimitRemaining = report.RateLimit.Remaining
+		run.RateLimitReset = report.RateLimit.Reset
+	}
+
+	switch {
+	case errors.Is(runErr, context.Canceled) || errors.Is(runErr, context.DeadlineExceeded):
+		run.Outcome = models.SyncOutcomeCancelled
+		run.Error = runErr.Error()
+	case runErr != nil:
+		run.Outcome = models.SyncOutcomeFailed
+		run.Error = runErr.Error()
+	case len(report.Failed()) > 0 || run.StaleCount > 0:
+		run.Outcome = models.SyncOutcomePartial
+	}
+
+	for _, repoReport := range report.Repositories {
+		run.Repositories = append(run.Repositories, repoReport.syncRunRepository())
+	}
+
+	return run
+}
+
+func (repoReport RepositoryReport) syncRunRepository() models.SyncRunRepository {
+	repoRun := models.SyncRunRepository{
+		Repository:   repoReport.Repository,
+		Outcome:      models.SyncOutcomeSuccess,
+		NewCount:     len(repoReport.NewPrs),
+		UpdatedCount: len(repoReport.UpdatedPrs),
+		RemovedCount: len(repoReport.ArchivedPrs) + len(repoReport.DeletedPrs),
+		StaleCount:   len(repoReport.FailedPrs),
+		Duration:     repoReport.Duration,
+	}
+
+	if repoReport.Err != nil {
+		repoRun.Outcome = models.SyncOutcomeFailed
+		repoRun.Error = repoReport.Err.Error()
+		return repoRun
+	}
+
+	if len(repoReport.FailedPrs) > 0 {
+		messages := make([]string, 0, len(repoReport.FailedPrs))
+		for _, failure := range repoReport.FailedPrs {
+			messages = append(messages, fmt.Sprintf("#%d: %v", failure.Number, failure.Err))
+		}
+		repoRun.Outcome = models.SyncOutcomePartial
+		repoRun.Error = strings.Join(messages, "; ")
+	}
+
+	return repoRun
+}
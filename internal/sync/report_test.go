@@ -0,0 +1,70 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"git.rileymathews.com/riley/pr-tracker/internal/models"
+	"git.rileymathews.com/riley/pr-tracker/internal/service"
+)
+
+// TestSyncRun_Outcome verifies how a report is classified when it is saved to
+// the sync history.
+func TestSyncRun_Outcome(t *testing.T) {
+	ok := RepositoryReport{Repository: "acme/ok", NewPrs: []*models.PullRequest{{Number: 1}}}
+	stale := RepositoryReport{
+		Repository: "acme/stale",
+		FailedPrs:  []service.PullRequestFailure{{Number: 2, Err: errors.New("boom")}},
+	}
+	failed := RepositoryReport{Repository: "acme/failed", Err: errors.New("not found")}
+
+	tests := []struct {
+		name    string
+		repos   []RepositoryReport
+		runErr  error
+		outcome models.SyncOutcome
+	}{
+		{name: "all repositories synced", repos: []RepositoryReport{ok}, outcome: models.SyncOutcomeSuccess},
+		{name: "stale pull request", repos: []RepositoryReport{ok, stale}, outcome: models.SyncOutcomePartial},
+		{name: "failed repository", repos: []RepositoryReport{ok, failed}, outcome: models.SyncOutcomePartial},
+		{name: "cancelled", repos: []RepositoryReport{ok}, runErr: context.Canceled, outcome: models.SyncOutcomeCancelled},
+		{name: "could not start", runErr: errors.New("db locked"), outcome: models.SyncOutcomeFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := &SyncReport{Repositories: tt.repos}
+			run := report.syncRun(tt.runErr)
+			if run.Outcome != tt.outcome {
+				t.Errorf("expected outcome %q, got %q", tt.outcome, run.Outcome)
+			}
+			if len(run.Repositories) != len(tt.repos) {
+				t.Errorf("expected %d repository rows, got %d", len(tt.repos), len(run.Repositories))
+			}
+		})
+	}
+}
+
+// TestSyncRunRepository_StaleErrors verifies that per-PR failures are kept
+// on the repository row so the history shows why data went stale.
+func TestSyncRunRepository_StaleErrors(t *testing.T) {
+	repoReport := RepositoryReport{
+		Repository: "acme/repo",
+		FailedPrs: []service.PullRequestFailure{
+			{Number: 2, Err: errors.New("boom")},
+			{Number: 3, Err: errors.New("bang")},
+		},
+	}
+
+	repoRun := repoReport.syncRunRepository()
+	if repoRun.Outcome != models.SyncOutcomePartial {
+		t.Errorf("expected outcome %q, got %q", models.SyncOutcomePartial, repoRun.Outcome)
+	}
+	if repoRun.StaleCount != 2 {
+		t.Errorf("expected 2 stale PRs, got %d", repoRun.StaleCount)
+	}
+	if want := "#2: boom; #3: bang"; repoRun.Error != want {
+		t.Errorf("expected error %q, got %q", want, repoRun.Error)
+	}
+}
@@ -0,0 +1,323 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"git.rileymathews.com/riley/pr-tracker/internal/core"
+	"git.rileymathews.com/riley/pr-tracker/internal/db/repository"
+	gh "git.rileymathews.com/riley/pr-tracker/internal/github"
+	"git.rileymathews.com/riley/pr-tracker/internal/models"
+	"git.rileymathews.com/riley/pr-tracker/internal/service"
+)
+
+// cachedResponseMaxAge is how long a cached GitHub response is kept without
+// being refreshed. Anything still in use is refreshed whenever it changes, so
+// this mostly clears out URLs for pull requests that are no longer open.
+const cachedResponseMaxAge = 30 * 24 * time.Hour
+
+// DefaultFullResyncInterval is how long a pull request can go without being
+// fetched in full when Options.FullResyncInterval is not set.
+const DefaultFullResyncInterval = time.Hour
+
+// Options controls how a sync run fetches from GitHub.
+type Options struct {
+	// Parallelism is the maximum number of GitHub fetches in flight at once.
+	// Zero uses service.DefaultParallelism.
+	Parallelism int
+
+	// FullResyncInterval bounds how long a pull request that looks unchanged
+	// in the open pull request listing keeps being skipped. Some changes,
+	// such as a check being re-run or the base branch moving on, don't show
+	// up in the listing, so every pull request is fetched in full at least
+	// this often. Zero uses
+	// DefaultFullResyncInterval.
+	FullResyncInterval time.Duration
+
+	// Full fetches every pull request in full regardless of when it was last
+	// fetched.
+	Full bool
+
+	// GitHub holds the client settings shared by every host, such as the
+	// response cache, retry policy and transport wrapper. Each host's own
+	// settings fill in the rest; see NewClient.
+	GitHub gh.Config
+}
+
+// refetchBefore returns the time before which a pull request's last full
+// fetch is considered too old to skip another.
+func (opts Options) refetchBefore(now time.Time) time.Time {
+	if opts.Full {
+		return now
+	}
+
+	interval := opts.FullResyncInterval
+	if interval <= 0 {
+		interval = DefaultFullResyncInterval
+	}
+	return now.Add(-interval)
+}
+
+// Run fetches the open pull requests for every tracked repository and applies
+// the differences to the database. Repositories are fetched concurrently, then
+// each repository's changes are written in its own transaction, in tracking
+// order. token is the authenticated user's github.com token; repositories on
+// other hosts use the token configured for their host. Cancellation of ctx
+// stops any outstanding fetches and is otherwise only honoured between
+// repository writes, so a sync is never left half applied. Pull requests the
+// open pull request listing shows as unchanged since their last fetch are not
+// fetched again; see Options.FullResyncInterval.
+//
+// Failures for individual repositories are recorded in the returned report
+// rather than returned as an error. An error is only returned when the sync
+// could not run at all or was cancelled, and the report then covers the
+// repositories that were written before it stopped. Every run, including
+// failed and cancelled ones, is saved to the sync history.
+func Run(ctx context.Context, repo *repository.DatabaseRepository, token string, opts Options) (*SyncReport, error) {
+	report, err := run(ctx, repo, token, opts)
+
+	// The history is written even when ctx was cancelled, since a cancelled
+	// run is exactly what it needs to record.
+	bookkeepingCtx := context.WithoutCancel(ctx)
+	if _, saveErr := repo.SaveSyncRun(bookkeepingCtx, report.syncRun(err)); saveErr != nil {
+		log.Printf("save sync run history failed: %v", saveErr)
+	}
+	if pruneErr := repo.PruneCachedResponses(bookkeepingCtx, report.StartedAt.Add(-cachedResponseMaxAge)); pruneErr != nil {
+		log.Printf("prune cached responses failed: %v", pruneErr)
+	}
+
+	return report, err
+}
+
+func run(ctx context.Context, repo *repository.DatabaseRepository, token string, opts Options) (*SyncReport, error) {
+	report := &SyncReport{StartedAt: time.Now().UTC()}
+	startRequests := gh.RequestCount()
+	startCached := gh.CachedResponseCount()
+	startRetries := gh.RetryCount()
+	var clients *hostClients
+	defer func() {
+		report.FinishedAt = time.Now().UTC()
+		report.APICalls = gh.RequestCount() - startRequests
+		report.CachedResponses = gh.CachedResponseCount() - startCached
+		report.Retries = gh.RetryCount() - startRetries
+		if client, ok := clients.defaultClient(); ok {
+			report.RateLimit = client.CurrentCoreRateLimit()
+		}
+	}()
+
+	repositories, err := repo.GetTrackedRepositories(ctx)
+	if err != nil {
+		return report, fmt.Errorf("fetch tracked repositories: %w", err)
+	}
+	trackedAuthors, err := repo.GetTrackedAuthors(ctx)
+	if err != nil {
+		return report, fmt.Errorf("fetch tracked authors: %w", err)
+	}
+	if len(repositories) == 0 || len(trackedAuthors) == 0 {
+		return report, nil
+	}
+
+	ignoredCommenters, err := ignoredCommenters(ctx, repo)
+	if err != nil {
+		return report, err
+	}
+	hosts, err := repo.GetGitHubHosts(ctx)
+	if err != nil {
+		return report, fmt.Errorf("fetch github hosts: %w", err)
+	}
+	clients = newHostClients(hosts, token, opts.GitHub)
+
+	// Repositories on hosts without a usable client fail up front; the rest
+	// are fetched together so they share one parallelism limit.
+	var targets []service.TrackedRepository
+	hostErrs := map[string]error{}
+	refetchBefore := opts.refetchBefore(report.StartedAt)
+	for _, repoName := range repositories {
+		client, err := clients.forRepository(repoName)
+		if err != nil {
+			hostErrs[repoName] = err
+			continue
+		}
+
+		knownPrs, err := repo.GetPrsByRepository(ctx, repoName)
+		if err != nil {
+			return report, fmt.Errorf("fetch stored prs for %s: %w", repoName, err)
+		}
+		known := make(map[int]*models.PullRequest, len(knownPrs))
+		for _, pr := range knownPrs {
+			known[pr.Number] = pr
+		}
+
+		targets = append(targets, service.TrackedRepository{
+			Name:              repoName,
+			Client:            client,
+			Known:             known,
+			RefetchBefore:     refetchBefore,
+			IgnoredCommenters: ignoredCommenters,
+		})
+	}
+
+	results := service.FetchTrackedRepositories(ctx, targets, trackedAuthors, opts.Parallelism)
+	for _, repoName := range repositories {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		if err, ok := hostErrs[repoName]; ok {
+			report.Repositories = append(report.Repositories, RepositoryReport{Repository: repoName, Err: err})
+			continue
+		}
+
+		report.Repositories = append(report.Repositories, applyRepository(ctx, repo, results[0], nil))
+		results = results[1:]
+	}
+
+	return report, nil
+}
+
+// ignoredCommenters returns the logins whose comments shouldn't mark a pull
+// request as having a new comment: the authenticated user's own and the ones
+// configured with "cli commenters ignore".
+func ignoredCommenters(ctx context.Context, repo *repository.DatabaseRepository) ([]string, error) {
+	ignored, err := repo.GetIgnoredCommenters(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetch ignored commenters: %w", err)
+	}
+
+	user, err := repo.GetUser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetch user: %w", err)
+	}
+	if user != nil && user.Username != "" {
+		ignored = append(ignored, user.Username)
+	}
+	return ignored, nil
+}
+
+// applyRepository writes the fetched pull requests for one repository in a
+// single transaction. scope limits the stored pull requests they are compared
+// against to those numbers, for when only part of the repository was fetched;
+// nil compares against the whole repository, so anything missing from the
+// result is archived if it was merged or closed, and deleted otherwise.
+func applyRepository(ctx context.Context, repo *repository.DatabaseRepository, result service.RepositoryPullRequests, scope []int) RepositoryReport {
+	repoName := result.Repository
+	repoReport := RepositoryReport{
+		Repository: repoName,
+		Duration:   result.Duration,
+		APICalls:   result.APICalls,
+	}
+	if result.Err != nil {
+		repoReport.Err = fmt.Errorf("fetch open prs: %w", result.Err)
+		return repoReport
+	}
+
+	syncedAt := time.Now().UTC()
+	for _, pr := range result.PullRequests {
+		pr.LastSyncedAt = syncedAt
+	}
+
+	// Once a repository's writes have started they run to completion even if
+	// ctx is cancelled; Run checks for cancellation before the next one.
+	writeCtx := context.WithoutCancel(ctx)
+
+	var newPrs, updatedPrs, archivedPrs, deletedPrs []*models.PullRequest
+	var events []models.ChangeEvent
+	err := repo.WithTx(writeCtx, func(txRepo *repository.DatabaseRepository) error {
+		existingPrs, err := txRepo.GetPrsByRepository(writeCtx, repoName)
+		if err != nil {
+			return fmt.Errorf("fetch existing prs: %w", err)
+		}
+		if scope != nil {
+			existingPrs = slices.DeleteFunc(existingPrs, func(pr *models.PullRequest) bool {
+				return !slices.Contains(scope, pr.Number)
+			})
+		}
+
+		newPrs, updatedPrs, deletedPrs, events = core.ProcessPullRequestSyncResults(existingPrs, result.PullRequests)
+		// Pull requests that failed to fetch are missing from the fresh
+		// data but are still open, so they keep their last known row.
+		deletedPrs = withoutFailures(deletedPrs, result.Failures)
+
+		var closeEvents []models.ChangeEvent
+		archivedPrs, deletedPrs, closeEvents = core.ArchiveClosedPullRequests(deletedPrs, result.Closed)
+		events = append(events, closeEvents...)
+
+		for _, pr := range newPrs {
+			if err := txRepo.SavePr(writeCtx, pr); err != nil {
+				return fmt.Errorf("save pr #%d: %w", pr.Number, err)
+			}
+		}
+
+		for _, pr := range updatedPrs {
+			if err := txRepo.SavePr(writeCtx, pr); err != nil {
+				return fmt.Errorf("update pr #%d: %w", pr.Number, err)
+			}
+		}
+
+		for _, pr := range archivedPrs {
+			if err := txRepo.SavePr(writeCtx, pr); err != nil {
+				return fmt.Errorf("archive pr #%d: %w", pr.Number, err)
+			}
+		}
+
+		for _, pr := range deletedPrs {
+			if err := txRepo.DeletePr(writeCtx, pr.Repository, pr.Number); err != nil {
+				return fmt.Errorf("delete pr #%d: %w", pr.Number, err)
+			}
+		}
+
+		if err := txRepo.SavePrEvents(writeCtx, events, syncedAt); err != nil {
+			return err
+		}
+
+		for _, pr := range result.PullRequests {
+			if err := txRepo.MarkPrSynced(writeCtx, pr, syncedAt); err != nil {
+				return fmt.Errorf("mark pr #%d synced: %w", pr.Number, err)
+			}
+		}
+
+		for _, failure := range result.Failures {
+			if err := txRepo.MarkPrSyncFailed(writeCtx, repoName, failure.Number, failure.Err.Error()); err != nil {
+				return fmt.Errorf("mark pr #%d stale: %w", failure.Number, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		repoReport.Err = err
+		return repoReport
+	}
+
+	repoReport.NewPrs = newPrs
+	repoReport.UpdatedPrs = updatedPrs
+	repoReport.ArchivedPrs = archivedPrs
+	repoReport.DeletedPrs = deletedPrs
+	repoReport.Events = events
+	repoReport.Unchanged = result.Unchanged
+	repoReport.FailedPrs = result.Failures
+	return repoReport
+}
+
+func withoutFailures(prs []*models.PullRequest, failures []service.PullRequestFailure) []*models.PullRequest {
+	if len(failures) == 0 {
+		return prs
+	}
+
+	failed := make(map[int]struct{}, len(failures))
+	for _, failure := range failures {
+		failed[failure.Number] = struct{}{}
+	}
+
+	kept := make([]*models.PullRequest, 0, len(prs))
+	for _, pr := range prs {
+		if _, ok := failed[pr.Number]; ok {
+			continue
+		}
+		kept = append(kept, pr)
+	}
+	return kept
+}
@@ -0,0 +1,90 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"git.rileymathews.com/riley/pr-tracker/internal/db/repository"
+	"git.rileymathews.com/riley/pr-tracker/internal/models"
+	"git.rileymathews.com/riley/pr-tracker/internal/service"
+	"git.rileymathews.com/riley/pr-tracker/internal/webhook"
+)
+
+// ApplyEvent refetches the pull requests a webhook event touched and applies
+// what changed through the same change detection as Run, so a pull request
+// updated by a webhook looks exactly as if a sync had picked it up. Only
+// those pull requests are compared, so one that was merged or closed is
+// archived, and one whose author is no longer tracked is deleted, while the
+// rest of the repository is left alone.
+//
+// A nil report is returned when the event is for a repository that isn't
+// tracked or doesn't match any pull request. Events are not recorded in the
+// sync history.
+func ApplyEvent(ctx context.Context, repo *repository.DatabaseRepository, token string, event webhook.Event, opts Options) (*RepositoryReport, error) {
+	repositories, err := repo.GetTrackedRepositories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetch tracked repositories: %w", err)
+	}
+	if !slices.Contains(repositories, event.Repository) {
+		return nil, nil
+	}
+
+	storedPrs, err := repo.GetPrsByRepository(ctx, event.Repository)
+	if err != nil {
+		return nil, fmt.Errorf("fetch stored prs: %w", err)
+	}
+
+	numbers := eventPullRequests(storedPrs, event)
+	if len(numbers) == 0 {
+		return nil, nil
+	}
+
+	trackedAuthors, err := repo.GetTrackedAuthors(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetch tracked authors: %w", err)
+	}
+	ignoredCommenters, err := ignoredCommenters(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	hosts, err := repo.GetGitHubHosts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetch github hosts: %w", err)
+	}
+	client, err := newHostClients(hosts, token, opts.GitHub).forRepository(event.Repository)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[int]*models.PullRequest, len(storedPrs))
+	for _, pr := range storedPrs {
+		known[pr.Number] = pr
+	}
+
+	target := service.TrackedRepository{Name: event.Repository, Client: client, Known: known, IgnoredCommenters: ignoredCommenters}
+	result := service.FetchPullRequests(ctx, target, numbers, trackedAuthors, opts.Parallelism)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	repoReport := applyRepository(ctx, repo, result, numbers)
+	return &repoReport, nil
+}
+
+// eventPullRequests returns the numbers of the pull requests event affects:
+// those it names, plus any stored pull request whose head commit it is about.
+func eventPullRequests(storedPrs []*models.PullRequest, event webhook.Event) []int {
+	numbers := slices.Clone(event.PullRequests)
+
+	if event.HeadSHA != "" {
+		for _, pr := range storedPrs {
+			if pr.HeadSHA == event.HeadSHA {
+				numbers = append(numbers, pr.Number)
+			}
+		}
+	}
+
+	slices.Sort(numbers)
+	return slices.Compact(numbers)
+}
@@ -0,0 +1,222 @@
+// Package webhook receives GitHub webhook deliveries and works out which pull
+// requests they affect. Applying the changes is left to the caller, which
+// refetches the affected pull requests the same way a sync does.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"git.rileymathews.com/riley/pr-tracker/internal/models"
+)
+
+// maxPayloadSize is the largest delivery GitHub sends; anything bigger is
+// rejected without being read.
+const maxPayloadSize = 25 << 20
+
+// Event is a webhook delivery reduced to what the tracker needs: the
+// repository, under the name it is tracked as, and the pull requests it
+// touched. Check and status events can arrive without pull request numbers,
+// for example for pull requests from forks, so HeadSHA is set for those and
+// the affected pull requests are found by their head commit instead.
+type Event struct {
+	Name         string
+	Action       string
+	DeliveryID   string
+	Repository   string
+	PullRequests []int
+	HeadSHA      string
+}
+
+func (event Event) String() string {
+	name := event.Name
+	if event.Action != "" {
+		name += "." + event.Action
+	}
+	return fmt.Sprintf("%s for %s (delivery %s)", name, event.Repository, event.DeliveryID)
+}
+
+// ErrUnsupportedEvent is returned by ParseEvent for event types the tracker
+// doesn't act on.
+var ErrUnsupportedEvent = errors.New("unsupported webhook event")
+
+type repositoryPayload struct {
+	FullName string `json:"full_name"`
+	HTMLURL  string `json:"html_url"`
+}
+
+type pullRequestRef struct {
+	Number int `json:"number"`
+}
+
+type checkPayload struct {
+	HeadSHA      string           `json:"head_sha"`
+	PullRequests []pullRequestRef `json:"pull_requests"`
+}
+
+// ParseEvent decodes a delivery of type name, the X-GitHub-Event header. An
+// event that touches no pull request, such as a comment on a plain issue, is
+// returned with neither PullRequests nor HeadSHA set.
+func ParseEvent(name string, payload []byte) (Event, error) {
+	var body struct {
+		Action      string            `json:"action"`
+		Repository  repositoryPayload `json:"repository"`
+		PullRequest *pullRequestRef   `json:"pull_request"`
+		Issue       *struct {
+			Number      int       `json:"number"`
+			PullRequest *struct{} `json:"pull_request"`
+		} `json:"issue"`
+		CheckRun   *checkPayload `json:"check_run"`
+		CheckSuite *checkPayload `json:"check_suite"`
+		SHA        string        `json:"sha"`
+	}
+	if err := json.Unmarshal(payload, &body); err != nil {
+		return Event{}, fmt.Errorf("decode %s payload: %w", name, err)
+	}
+
+	event := Event{Name: name, Action: body.Action}
+	switch name {
+	case "pull_request", "pull_request_review", "pull_request_review_comment":
+		if body.PullRequest == nil {
+			return Event{}, fmt.Errorf("%s payload has no pull_request", name)
+		}
+		event.PullRequests = []int{body.PullRequest.Number}
+	case "issue_comment":
+		if body.Issue == nil {
+			return Event{}, errors.New("issue_comment payload has no issue")
+		}
+		// Comments on plain issues arrive here too.
+		if body.Issue.PullRequest != nil {
+			event.PullRequests = []int{body.Issue.Number}
+		}
+	case "check_run", "check_suite":
+		check := body.CheckRun
+		if name == "check_suite" {
+			check = body.CheckSuite
+		}
+		if check == nil {
+			return Event{}, fmt.Errorf("%s payload has no %s", name, name)
+		}
+		for _, pr := range check.PullRequests {
+			event.PullRequests = append(event.PullRequests, pr.Number)
+		}
+		event.HeadSHA = check.HeadSHA
+	case "status":
+		event.HeadSHA = body.SHA
+	default:
+		return Event{}, fmt.Errorf("%w: %s", ErrUnsupportedEvent, name)
+	}
+
+	repoName, err := trackedRepositoryName(body.Repository)
+	if err != nil {
+		return Event{}, err
+	}
+	event.Repository = repoName
+
+	return event, nil
+}
+
+// trackedRepositoryName returns the name a repository is tracked under, which
+// is prefixed with the host for repositories outside github.com.
+func trackedRepositoryName(repo repositoryPayload) (string, error) {
+	if repo.FullName == "" {
+		return "", errors.New("payload has no repository")
+	}
+
+	repoURL, err := url.Parse(repo.HTMLURL)
+	if err != nil || repoURL.Host == "" || repoURL.Host == models.DefaultHost {
+		return repo.FullName, nil
+	}
+	return repoURL.Host + "/" + repo.FullName, nil
+}
+
+// Handler is an http.Handler that accepts webhook deliveries signed with
+// secret and passes the ones affecting pull requests to deliver. deliver is
+// called on the request goroutine and should hand the event off rather than
+// act on it, since GitHub gives up on deliveries that take more than a few
+// seconds.
+type Handler struct {
+	secret  []byte
+	deliver func(Event)
+}
+
+func NewHandler(secret string, deliver func(Event)) (*Handler, error) {
+	if secret == "" {
+		return nil, errors.New("webhook secret is required")
+	}
+
+	return &Handler{secret: []byte(secret), deliver: deliver}, nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPayloadSize))
+	if err != nil {
+		http.Error(w, "read payload failed", http.StatusBadRequest)
+		return
+	}
+
+	if err := verifySignature(h.secret, payload, r.Header.Get("X-Hub-Signature-256")); err != nil {
+		log.Printf("rejected webhook delivery %s: %v", r.Header.Get("X-GitHub-Delivery"), err)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	name := r.Header.Get("X-GitHub-Event")
+	if name == "ping" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	event, err := ParseEvent(name, payload)
+	if errors.Is(err, ErrUnsupportedEvent) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	event.DeliveryID = r.Header.Get("X-GitHub-Delivery")
+
+	if len(event.PullRequests) > 0 || event.HeadSHA != "" {
+		h.deliver(event)
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// verifySignature checks the X-Hub-Signature-256 header, an HMAC-SHA256 of
+// the payload keyed with the webhook secret.
+func verifySignature(secret, payload []byte, header string) error {
+	signature, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return errors.New("missing sha256 signature")
+	}
+
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("decode signature: %w", err)
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return errors.New("signature does not match")
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+const testSecret = "It's a Secret to Everybody"
+
+func readPayload(t *testing.T, name string) []byte {
+	t.Helper()
+
+	payload, err := os.ReadFile(filepath.Join("testdata", name+".json"))
+	if err != nil {
+		t.Fatalf("read payload: %v", err)
+	}
+	return payload
+}
+
+func sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// TestParseEvent runs each recorded delivery through ParseEvent.
+func TestParseEvent(t *testing.T) {
+	tests := []struct {
+		file             string
+		event            string
+		wantRepository   string
+		wantPullRequests []int
+		wantHeadSHA      string
+	}{
+		{file: "pull_request", event: "pull_request", wantRepository: "acme/widgets", wantPullRequests: []int{42}},
+		{file: "pull_request_review", event: "pull_request_review", wantRepository: "acme/widgets", wantPullRequests: []int{42}},
+		{file: "pull_request_review_comment", event: "pull_request_review_comment", wantRepository: "acme/widgets", wantPullRequests: []int{42}},
+		{file: "issue_comment", event: "issue_comment", wantRepository: "ghe.example.com/platform/api", wantPullRequests: []int{7}},
+		{file: "issue_comment_on_issue", event: "issue_comment", wantRepository: "acme/widgets"},
+		{file: "check_run", event: "check_run", wantRepository: "acme/widgets", wantPullRequests: []int{42}, wantHeadSHA: "9c1f4e2d7a8b3c5e6f7a8b9c0d1e2f3a4b5c6d7e"},
+		{file: "check_suite", event: "check_suite", wantRepository: "acme/widgets", wantHeadSHA: "0f0e0d0c0b0a09080706050403020100f0e0d0c0"},
+		{file: "status", event: "status", wantRepository: "acme/widgets", wantHeadSHA: "9c1f4e2d7a8b3c5e6f7a8b9c0d1e2f3a4b5c6d7e"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			event, err := ParseEvent(tt.event, readPayload(t, tt.file))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if event.Repository != tt.wantRepository {
+				t.Errorf("expected repository %q, got %q", tt.wantRepository, event.Repository)
+			}
+			if !slices.Equal(event.PullRequests, tt.wantPullRequests) {
+				t.Errorf("expected pull requests %v, got %v", tt.wantPullRequests, event.PullRequests)
+			}
+			if event.HeadSHA != tt.wantHeadSHA {
+				t.Errorf("expected head sha %q, got %q", tt.wantHeadSHA, event.HeadSHA)
+			}
+		})
+	}
+}
+
+// TestParseEvent_Unsupported verifies that events the tracker doesn't act on
+// are reported as unsupported.
+func TestParseEvent_Unsupported(t *testing.T) {
+	_, err := ParseEvent("push", readPayload(t, "push"))
+	if !errors.Is(err, ErrUnsupportedEvent) {
+		t.Fatalf("expected ErrUnsupportedEvent, got %v", err)
+	}
+}
+
+// TestHandler covers signature checks and which deliveries are passed on.
+func TestHandler(t *testing.T) {
+	pullRequest := readPayload(t, "pull_request")
+	onIssue := readPayload(t, "issue_comment_on_issue")
+
+	tests := []struct {
+		name        string
+		event       string
+		payload     []byte
+		signature   string
+		wantStatus  int
+		wantDeliver bool
+	}{
+		{name: "valid", event: "pull_request", payload: pullRequest, signature: sign(testSecret, pullRequest), wantStatus: http.StatusAccepted, wantDeliver: true},
+		{name: "wrong secret", event: "pull_request", payload: pullRequest, signature: sign("other", pullRequest), wantStatus: http.StatusUnauthorized},
+		{name: "missing signature", event: "pull_request", payload: pullRequest, wantStatus: http.StatusUnauthorized},
+		{name: "ping", event: "ping", payload: []byte(`{"zen":"Keep it logically awesome."}`), signature: sign(testSecret, []byte(`{"zen":"Keep it logically awesome."}`)), wantStatus: http.StatusOK},
+		{name: "unsupported", event: "push", payload: pullRequest, signature: sign(testSecret, pullRequest), wantStatus: http.StatusNoContent},
+		{name: "comment on issue", event: "issue_comment", payload: onIssue, signature: sign(testSecret, onIssue), wantStatus: http.StatusAccepted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var delivered []Event
+			handler, err := NewHandler(testSecret, func(event Event) {
+				delivered = append(delivered, event)
+			})
+			if err != nil {
+				t.Fatalf("new handler: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(tt.payload))
+			req.Header.Set("X-GitHub-Event", tt.event)
+			req.Header.Set("X-GitHub-Delivery", "72d3162e-cc78-11e3-81ab-4c9367dc0958")
+			if tt.signature != "" {
+				req.Header.Set("X-Hub-Signature-256", tt.signature)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if tt.wantDeliver != (len(delivered) == 1) {
+				t.Fatalf("expected delivered %v, got %d events", tt.wantDeliver, len(delivered))
+			}
+			if tt.wantDeliver && delivered[0].DeliveryID != "72d3162e-cc78-11e3-81ab-4c9367dc0958" {
+				t.Errorf("expected delivery id to be recorded, got %q", delivered[0].DeliveryID)
+			}
+		})
+	}
+}
+
+// TestVerifySignature uses the example from GitHub's webhook documentation.
+func TestVerifySignature(t *testing.T) {
+	header := "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"
+	if err := verifySignature([]byte(testSecret), []byte("Hello, World!"), header); err != nil {
+		t.Fatalf("expected documented signature to verify, got %v", err)
+	}
+}